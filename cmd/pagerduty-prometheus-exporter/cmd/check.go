@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/collector"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+var errChecksFailed = errors.New("checks failed")
+
+type endpointCheck struct {
+	name  string
+	check func(ctx context.Context) (string, error)
+}
+
+type checkResult struct {
+	name    string
+	details string
+	err     error
+}
+
+func newCheckCommand() *cobra.Command {
+	var (
+		o       options
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:          "check",
+		Short:        "Checks pagerduty credentials and API access of enabled collectors",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := prepareOptions(&o); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			defer cancel()
+
+			results := runEndpointChecks(ctx, resolveEndpointChecks(pagerduty.NewExtendedClient(o.PagerdutyAuthToken), &o))
+
+			if err := writeCheckResults(cmd.OutOrStdout(), results); err != nil {
+				return err
+			}
+
+			for _, res := range results {
+				if res.err != nil {
+					return errChecksFailed
+				}
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, "checks timeout")
+
+	addCollectorFlags(flags, &o)
+
+	return cmd
+}
+
+func resolveEndpointChecks(client *pagerduty.ExtendedClient, opts *options) []endpointCheck {
+	checks := []endpointCheck{
+		{
+			name: "token",
+			check: func(ctx context.Context) (string, error) {
+				info, err := client.InspectToken(ctx)
+				if err != nil {
+					return "", err
+				}
+
+				details := fmt.Sprintf("type=%s abilities=%s", info.Type, strings.Join(info.Abilities, ","))
+				if info.Type == pagerduty.TokenTypeUser {
+					details = fmt.Sprintf("%s user=%s", details, info.UserEmail)
+				}
+
+				return details, nil
+			},
+		},
+	}
+
+	if opts.AnalyticsScrape {
+		checks = append(checks, endpointCheck{
+			name: "analytics",
+			check: func(ctx context.Context) (string, error) {
+				t := time.Now()
+
+				report, err := client.QueryMetricReport(ctx, pagerduty.ServiceMetricReportParams{
+					TimeZone: collector.UTCTimeZone,
+					Filters: pagerduty.ServiceMetricReportFilters{
+						CreatedAtStart: pagerduty.ReportTime(t.Add(-time.Hour)),
+						CreatedAtEnd:   pagerduty.ReportTime(t),
+					},
+				})
+				if err != nil {
+					return "", err
+				}
+
+				return fmt.Sprintf("services=%d", len(report.Data)), nil
+			},
+		})
+	}
+
+	if opts.UsersScrape {
+		checks = append(checks, probeEndpointCheck(client, "users", "/users"))
+	}
+
+	if opts.ServicesScrape {
+		checks = append(checks, probeEndpointCheck(client, "services", "/services"))
+	}
+
+	if opts.EscalationPoliciesScrape {
+		checks = append(checks, probeEndpointCheck(client, "escalation_policies", "/escalation_policies"))
+	}
+
+	if opts.PrioritiesScrape {
+		checks = append(checks, probeEndpointCheck(client, "priorities", "/priorities"))
+	}
+
+	if opts.TeamsScrape {
+		checks = append(checks, probeEndpointCheck(client, "teams", "/teams"))
+	}
+
+	if opts.MaintenanceWindowsScrape {
+		checks = append(checks, probeEndpointCheck(client, "maintenance_windows", "/maintenance_windows"))
+	}
+
+	if opts.BusinessServicesScrape {
+		checks = append(checks, endpointCheck{
+			name: "business_services",
+			check: func(ctx context.Context) (string, error) {
+				if err := client.ProbeBusinessServiceImpacts(ctx); err != nil {
+					return "", err
+				}
+
+				return "/business_services/impacts", nil
+			},
+		})
+	}
+
+	if opts.LogEntriesScrape {
+		checks = append(checks, probeEndpointCheck(client, "log_entries", "/log_entries"))
+	}
+
+	if opts.NotificationsScrape {
+		// /notifications requires since and until
+		t := time.Now().UTC()
+
+		params := url.Values{}
+		params.Set("since", t.Add(-time.Hour).Format(time.RFC3339))
+		params.Set("until", t.Format(time.RFC3339))
+
+		checks = append(checks, probeEndpointCheck(client, "notifications", "/notifications?"+params.Encode()))
+	}
+
+	return checks
+}
+
+func probeEndpointCheck(client *pagerduty.ExtendedClient, name, path string) endpointCheck {
+	return endpointCheck{
+		name: name,
+		check: func(ctx context.Context) (string, error) {
+			if err := client.ProbeEndpoint(ctx, path); err != nil {
+				return "", err
+			}
+
+			return path, nil
+		},
+	}
+}
+
+func runEndpointChecks(ctx context.Context, checks []endpointCheck) []checkResult {
+	results := make([]checkResult, len(checks))
+
+	for i, c := range checks {
+		details, err := c.check(ctx)
+
+		results[i] = checkResult{
+			name:    c.name,
+			details: details,
+			err:     err,
+		}
+	}
+
+	return results
+}
+
+func writeCheckResults(w io.Writer, results []checkResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAILS")
+
+	for _, res := range results {
+		status, details := "PASS", res.details
+		if res.err != nil {
+			status, details = "FAIL", res.err.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", res.name, status, details)
+	}
+
+	return tw.Flush()
+}
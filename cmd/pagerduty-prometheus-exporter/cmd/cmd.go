@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,33 +16,105 @@ import (
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/model"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/24el/pagerduty-prometheus-exporter/cmd/pagerduty-prometheus-exporter/cmd/httphandler"
 	"github.com/24el/pagerduty-prometheus-exporter/cmd/pagerduty-prometheus-exporter/cmd/middleware"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/collector"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/collector/webhook"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/labelpolicy"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/relabel"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/relay"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/tlsconfig"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/version"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/webconfig"
 )
 
 type srvShutdowner func(context.Context) error
 
 type options struct {
-	MetricsSrvPort int
-	WebhookSrvPort int
-
-	IncidentWebhookSignatureSecret string `envconfig:"incident_webhook_signature_secret"`
-	IncidentWebhookPath            string
+	MetricsSrvPort          int
+	MetricsWebConfigFile    string
+	MetricsGoCollector      bool
+	MetricsProcessCollector bool
+	MetricsBuildInfo        bool
+	WebhookSrvPort          int
+
+	IncidentWebhookSignatureSecret   string `envconfig:"incident_webhook_signature_secret"`
+	IncidentWebhookPath              string
+	IncidentWebhookV2SignatureSecret string `envconfig:"incident_webhook_v2_signature_secret"`
+	IncidentWebhookV2Path            string
+	IncidentWebhookPriorityName      bool
+
+	WebhookQueueSize   int
+	WebhookWorkers     int
+	WebhookRelayConfig string
+
+	WebhookTLSCertFile     string
+	WebhookTLSKeyFile      string
+	WebhookTLSClientCAFile string
+	WebhookAllowedCIDRs    []string
+	WebhookTrustedProxies  []string
+
+	WebhookMaxBodySize           int64
+	WebhookReadHeaderTimeout     time.Duration
+	WebhookReadTimeout           time.Duration
+	WebhookWriteTimeout          time.Duration
+	WebhookIdleTimeout           time.Duration
+	WebhookMaxConcurrentRequests int
+	WebhookRateLimit             float64
+	WebhookRateLimitBurst        int
 
 	MetricsPrefix               string
+	AnalyticsScrape             bool
 	AnalyticsScrapeInterval     time.Duration
 	AnalyticsReportPeriods      []time.Duration
 	AnalyticsServiceMetricNames []string
+	UsersScrape                 bool
 	UsersScrapeInterval         time.Duration
 
+	ServicesScrape                       bool
+	ServicesScrapeInterval               time.Duration
+	EscalationPoliciesScrape             bool
+	EscalationPoliciesScrapeInterval     time.Duration
+	PrioritiesScrape                     bool
+	PrioritiesScrapeInterval             time.Duration
+	TeamsScrape                          bool
+	TeamsScrapeInterval                  time.Duration
+	MaintenanceWindowsScrape             bool
+	MaintenanceWindowsScrapeInterval     time.Duration
+	BusinessServicesScrape               bool
+	BusinessServicesScrapeInterval       time.Duration
+	BusinessServicesDependenciesInterval time.Duration
+	LogEntriesScrape                     bool
+	LogEntriesScrapeInterval             time.Duration
+	LogEntriesStateFile                  string
+	LogEntriesLookBack                   time.Duration
+	NotificationsScrape                  bool
+	NotificationsScrapeInterval          time.Duration
+	NotificationsWindow                  time.Duration
+	NotificationsNightStartHour          int
+	NotificationsNightEndHour            int
+
+	MetricsStripDescriptiveLabels bool
+
+	MetricsRelabelConfig string
+	MetricsConstLabels   map[string]string
+
+	MetricsMaxSeriesPerFamily int
+	MetricsSeriesLimitAction  string
+
+	LabelPolicy        []string
+	LabelPolicyHashKey string `envconfig:"label_policy_hash_key"`
+
 	DTFormat string
 
 	PagerdutyAuthToken string `envconfig:"pagerduty_auth_token"`
@@ -54,12 +128,7 @@ func NewPagerdutyPrometheusExporterCommand() *cobra.Command {
 		Use:   "pagerduty-prometheus-exporter",
 		Short: "Exports pagerduty to prometheus",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logger, err := createLogger(o.Debug)
-			if err != nil {
-				return err
-			}
-
-			err = envconfig.Process("", &o)
+			logger, err := prepareOptions(&o)
 			if err != nil {
 				return err
 			}
@@ -71,10 +140,48 @@ func NewPagerdutyPrometheusExporterCommand() *cobra.Command {
 	flags := cmd.Flags()
 
 	flags.IntVar(&o.MetricsSrvPort, "metrics-srv-port", 9100, "metrics server port")
+	flags.StringVar(&o.MetricsWebConfigFile, "metrics-web-config-file", "", "metrics server web config file with tls and authentication settings, reloaded on change")
+	flags.BoolVar(&o.MetricsGoCollector, "metrics-go-collector", true, "export go runtime metrics")
+	flags.BoolVar(&o.MetricsProcessCollector, "metrics-process-collector", true, "export process metrics")
+	flags.BoolVar(&o.MetricsBuildInfo, "metrics-build-info", true, "export pagerduty_exporter_build_info metric")
 	flags.IntVar(&o.WebhookSrvPort, "webhook-srv-port", 8080, "webhook server port")
 	flags.StringVar(&o.IncidentWebhookSignatureSecret, "incident-webhook-signature-secret", "", "incident webhook signature secret")
 	flags.StringVar(&o.IncidentWebhookPath, "incident-webhook-path", "/v1/incidents", "incident webhook path")
+	flags.StringVar(&o.IncidentWebhookV2Path, "incident-webhook-v2-path", "", "legacy v2 incident webhook path, disabled if empty")
+	flags.StringVar(&o.IncidentWebhookV2SignatureSecret, "incident-webhook-v2-signature-secret", "", "legacy v2 incident webhook signature secret, signature is not verified if empty")
+	flags.BoolVar(&o.IncidentWebhookPriorityName, "incident-webhook-priority-name", false, "add priority_name label to incident metrics from priorities cache, requires --priorities-scrape")
+	flags.IntVar(&o.WebhookQueueSize, "webhook-queue-size", 1000, "webhook events queue size, events are rejected with 503 when the queue is full")
+	flags.IntVar(&o.WebhookWorkers, "webhook-workers", 1, "webhook events processing workers")
+	flags.StringVar(&o.WebhookRelayConfig, "webhook-relay-config", "", "webhook relay config file, verified events are forwarded to its destinations")
+	flags.StringVar(&o.WebhookTLSCertFile, "webhook-tls-cert-file", "", "webhook server tls certificate file, reloaded on change")
+	flags.StringVar(&o.WebhookTLSKeyFile, "webhook-tls-key-file", "", "webhook server tls key file, reloaded on change")
+	flags.StringVar(&o.WebhookTLSClientCAFile, "webhook-tls-client-ca-file", "", "webhook server client ca file, client certificates are required if set")
+	flags.StringSliceVar(&o.WebhookAllowedCIDRs, "webhook-allowed-cidrs", nil, "webhook source networks allowlist, all sources are allowed if empty")
+	flags.StringSliceVar(&o.WebhookTrustedProxies, "webhook-trusted-proxies", nil, "proxy networks whose X-Forwarded-For header is trusted")
+	flags.Int64Var(&o.WebhookMaxBodySize, "webhook-max-body-size", 1<<20, "webhook request max body size in bytes, larger requests are rejected with 413")
+	flags.DurationVar(&o.WebhookReadHeaderTimeout, "webhook-read-header-timeout", 10*time.Second, "webhook server request headers read timeout")
+	flags.DurationVar(&o.WebhookReadTimeout, "webhook-read-timeout", 30*time.Second, "webhook server request read timeout")
+	flags.DurationVar(&o.WebhookWriteTimeout, "webhook-write-timeout", 30*time.Second, "webhook server response write timeout")
+	flags.DurationVar(&o.WebhookIdleTimeout, "webhook-idle-timeout", 2*time.Minute, "webhook server keep-alive connections idle timeout")
+	flags.IntVar(&o.WebhookMaxConcurrentRequests, "webhook-max-concurrent-requests", 100, "webhook requests handled at the same time, others are rejected with 503, unlimited if 0")
+	flags.Float64Var(&o.WebhookRateLimit, "webhook-rate-limit", 0, "webhook requests per second allowed per source ip, others are rejected with 429, unlimited if 0")
+	flags.IntVar(&o.WebhookRateLimitBurst, "webhook-rate-limit-burst", 20, "webhook requests burst allowed per source ip")
+	flags.StringVar(&o.DTFormat, "dt-format", time.RFC3339, "dt format")
+
+	addCollectorFlags(flags, &o)
+
+	cmd.AddCommand(
+		newCheckCommand(),
+		newDumpCommand(),
+		newWebhookCommand(),
+	)
+
+	return cmd
+}
+
+func addCollectorFlags(flags *pflag.FlagSet, o *options) {
 	flags.StringVar(&o.MetricsPrefix, "metrics-prefix", "", "metrics prefix")
+	flags.BoolVar(&o.AnalyticsScrape, "analytics-scrape", true, "scrape service analytic metrics")
 	flags.DurationVar(&o.AnalyticsScrapeInterval, "analytics-scrape-interval", time.Minute, "scrape service analytic metric interval")
 	flags.StringSliceVar(
 		&o.AnalyticsServiceMetricNames,
@@ -94,12 +201,61 @@ func NewPagerdutyPrometheusExporterCommand() *cobra.Command {
 		[]time.Duration{time.Hour * 24 * 90},
 		"scrape service analytic metric periods",
 	)
+	flags.BoolVar(&o.UsersScrape, "users-scrape", true, "scrape users")
 	flags.DurationVar(&o.UsersScrapeInterval, "users-scrape-interval", 5*time.Minute, "scrape users interval")
-	flags.StringVar(&o.DTFormat, "dt-format", time.RFC3339, "dt format")
+	flags.BoolVar(&o.ServicesScrape, "services-scrape", false, "scrape services info, status, timeouts and integrations")
+	flags.DurationVar(&o.ServicesScrapeInterval, "services-scrape-interval", 5*time.Minute, "scrape services interval")
+	flags.BoolVar(&o.EscalationPoliciesScrape, "escalation-policies-scrape", false, "scrape escalation policies info and audit metrics")
+	flags.DurationVar(&o.EscalationPoliciesScrapeInterval, "escalation-policies-scrape-interval", 5*time.Minute, "scrape escalation policies interval")
+	flags.BoolVar(&o.PrioritiesScrape, "priorities-scrape", false, "scrape incident priorities")
+	flags.DurationVar(&o.PrioritiesScrapeInterval, "priorities-scrape-interval", 30*time.Minute, "scrape incident priorities interval")
+	flags.BoolVar(&o.TeamsScrape, "teams-scrape", false, "scrape teams and team members")
+	flags.DurationVar(&o.TeamsScrapeInterval, "teams-scrape-interval", 5*time.Minute, "scrape teams interval")
+	flags.BoolVar(&o.MaintenanceWindowsScrape, "maintenance-windows-scrape", false, "scrape active and upcoming maintenance windows")
+	flags.DurationVar(&o.MaintenanceWindowsScrapeInterval, "maintenance-windows-scrape-interval", time.Minute, "scrape maintenance windows interval")
+	flags.BoolVar(&o.BusinessServicesScrape, "business-services-scrape", false, "scrape business services, impact status and service dependencies")
+	flags.DurationVar(&o.BusinessServicesScrapeInterval, "business-services-scrape-interval", 5*time.Minute, "scrape business services interval")
+	flags.DurationVar(
+		&o.BusinessServicesDependenciesInterval,
+		"business-services-dependencies-interval",
+		time.Hour,
+		"list service dependencies interval, the dependency graph is reused by the business services scrapes in between",
+	)
+	flags.BoolVar(&o.LogEntriesScrape, "log-entries-scrape", false, "count notifications, escalations and acknowledgements from log entries")
+	flags.DurationVar(&o.LogEntriesScrapeInterval, "log-entries-scrape-interval", time.Minute, "scrape log entries interval")
+	flags.StringVar(&o.LogEntriesStateFile, "log-entries-state-file", "", "file the log entries cursor is persisted to, so entries are not counted again after restart, kept in memory if empty")
+	flags.BoolVar(&o.NotificationsScrape, "notifications-scrape", false, "scrape per user notifications in the sliding window")
+	flags.DurationVar(&o.NotificationsScrapeInterval, "notifications-scrape-interval", 15*time.Minute, "scrape notifications interval")
+	flags.DurationVar(&o.NotificationsWindow, "notifications-window", 7*24*time.Hour, "sliding window notifications are counted in")
+	flags.IntVar(&o.NotificationsNightStartHour, "notifications-night-start-hour", 22, "hour of day night starts at in the user time zone")
+	flags.IntVar(&o.NotificationsNightEndHour, "notifications-night-end-hour", 7, "hour of day night ends at in the user time zone")
+	flags.BoolVar(
+		&o.MetricsStripDescriptiveLabels,
+		"metrics-strip-descriptive-labels",
+		false,
+		"strip service names and service and team summaries from value metrics, join them from info metrics instead",
+	)
+	flags.StringToStringVar(&o.MetricsConstLabels, "metrics-const-label", nil, "constant label in key=value form added to every metric, repeatable")
+	flags.IntVar(&o.MetricsMaxSeriesPerFamily, "metrics-max-series-per-family", 0, "max series of users and webhook metric families, unlimited if 0")
+	flags.StringVar(&o.MetricsSeriesLimitAction, "metrics-series-limit-action", string(cardinality.ActionOverflow), "new label sets past the series limit are folded into __overflow__ series with overflow or dropped with drop")
+	flags.StringVar(&o.MetricsRelabelConfig, "metrics-relabel-config", "", "metric relabel config file applied to metric families before exposition")
+	flags.StringSliceVar(&o.LabelPolicy, "label-policy", nil, "sensitive label rules in label=drop|hash|truncate:length form, e.g. mail=hash,title=truncate:32")
+	flags.StringVar(&o.LabelPolicyHashKey, "label-policy-hash-key", "", "label policy HMAC key for hashed label values")
 	flags.StringVar(&o.PagerdutyAuthToken, "pagerduty-auth-token", "", "pagerduty auth token")
 	flags.BoolVar(&o.Debug, "debug", false, "debug")
+}
 
-	return cmd
+func prepareOptions(o *options) (*zap.Logger, error) {
+	logger, err := createLogger(o.Debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("", o); err != nil {
+		return nil, err
+	}
+
+	return logger, nil
 }
 
 func run(logger *zap.Logger, opts *options) error {
@@ -108,11 +264,28 @@ func run(logger *zap.Logger, opts *options) error {
 
 	eg, gCtx := errgroup.WithContext(ctx)
 
-	metricsSrv := createMetricsServer(opts)
+	registry := prometheus.NewRegistry()
+
+	registerer, err := wrapRegisterer(registry, opts)
+	if err != nil {
+		return err
+	}
+
+	registerExporterCollectors(registerer, opts)
+
+	metricsSrv, err := createMetricsServer(logger, registry, registerer, opts)
+	if err != nil {
+		return errors.Wrap(err, "create metrics server")
+	}
+
+	limiter, err := createSeriesLimiter(logger, registerer, opts)
+	if err != nil {
+		return err
+	}
 
-	registerer := prometheus.WrapRegistererWithPrefix(opts.MetricsPrefix, prometheus.DefaultRegisterer)
+	priorityNames := collector.NewPriorityNames()
 
-	collectors, err := resolvePagerdutyMetricCollectors(logger, registerer, opts)
+	collectors, err := resolvePagerdutyMetricCollectors(logger, registerer, limiter, priorityNames, true, opts)
 	if err != nil {
 		return errors.Wrap(err, "resolve metric collectors")
 	}
@@ -122,7 +295,7 @@ func run(logger *zap.Logger, opts *options) error {
 	eg.Go(func() error {
 		logger.Info("Starting metrics server", zap.String("addr", metricsSrv.Addr))
 
-		if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := listenAndServe(metricsSrv); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			return errors.Wrap(err, "listen and serve metrics server")
 		}
 
@@ -130,14 +303,58 @@ func run(logger *zap.Logger, opts *options) error {
 	})
 
 	if opts.WebhookSrvPort != 0 {
-		webhookSrv := createWebhookServer(logger, registerer, opts)
+		if opts.WebhookQueueSize < 1 || opts.WebhookWorkers < 1 {
+			return errors.New("webhook queue size and workers must be positive")
+		}
+
+		if opts.IncidentWebhookPriorityName && !opts.PrioritiesScrape {
+			return errors.New("incident webhook priority name requires priorities scrape")
+		}
+
+		var forwarder httphandler.EventForwarder
+
+		// the relay is stopped only after the webhook server is shut down,
+		// so events forwarded by in-flight requests are still delivered or spooled
+		relayCtx, stopRelay := context.WithCancel(context.Background())
+		defer stopRelay()
+
+		if opts.WebhookRelayConfig != "" {
+			webhookRelay, err := createWebhookRelay(logger, registerer, opts)
+			if err != nil {
+				return errors.Wrap(err, "create webhook relay")
+			}
+
+			forwarder = webhookRelay
+
+			eg.Go(func() error {
+				return webhookRelay.Run(relayCtx)
+			})
+		}
+
+		labelPolicy, err := resolveLabelPolicy(opts)
+		if err != nil {
+			return err
+		}
+
+		webhookHandler := createWebhookHandler(logger, registerer, opts, labelPolicy, priorityNames, limiter, forwarder)
+		webhookSrv, err := createWebhookServer(logger, registerer, opts, webhookHandler)
+		if err != nil {
+			return errors.Wrap(err, "create webhook server")
+		}
+
+		srvShutdowners = append(srvShutdowners, webhookSrv.Shutdown, func(context.Context) error {
+			stopRelay()
+			return nil
+		})
 
-		srvShutdowners = append(srvShutdowners, webhookSrv.Shutdown)
+		eg.Go(func() error {
+			return webhookHandler.Run(gCtx)
+		})
 
 		eg.Go(func() error {
 			logger.Info("Starting webhook server", zap.String("addr", webhookSrv.Addr))
 
-			if err := webhookSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			if err := listenAndServe(webhookSrv); err != nil && !errors.Is(err, http.ErrServerClosed) {
 				return errors.Wrap(err, "listen and serve webhook server")
 			}
 
@@ -145,6 +362,11 @@ func run(logger *zap.Logger, opts *options) error {
 		})
 	}
 
+	// every metric family is registered by now, const labels colliding with their labels fail the startup
+	if err := registerer.Err(); err != nil {
+		return err
+	}
+
 	for i := range collectors {
 		cl := collectors[i]
 		eg.Go(func() error {
@@ -168,92 +390,453 @@ func run(logger *zap.Logger, opts *options) error {
 	return eg.Wait()
 }
 
-func createMetricsServer(opts *options) *http.Server {
+// exposedLabelNames are added to histogram and summary series at exposition, so they never show up in descriptors
+var exposedLabelNames = []string{"le", "quantile"}
+
+// checkedRegisterer registers collectors with Register and keeps the errors instead of panicking,
+// constant labels colliding with metric labels make the wrapped descriptors invalid and fail the startup
+type checkedRegisterer struct {
+	prometheus.Registerer
+
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *checkedRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			r.mu.Lock()
+			r.errs = append(r.errs, err)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Err returns the first registration error
+func (r *checkedRegisterer) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.errs) == 0 {
+		return nil
+	}
+
+	if len(r.errs) > 1 {
+		return errors.Wrapf(r.errs[0], "register metrics (and %d more errors)", len(r.errs)-1)
+	}
+
+	return errors.Wrap(r.errs[0], "register metrics")
+}
+
+// wrapRegisterer applies metrics prefix and constant labels to every metric registered in the registry
+func wrapRegisterer(registry *prometheus.Registry, opts *options) (*checkedRegisterer, error) {
+	for name := range opts.MetricsConstLabels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid metrics const label name %q", name)
+		}
+
+		for _, exposed := range exposedLabelNames {
+			if name == exposed {
+				return nil, fmt.Errorf("metrics const label name %q is used by exporter metrics", name)
+			}
+		}
+	}
+
+	return &checkedRegisterer{
+		Registerer: prometheus.WrapRegistererWith(
+			opts.MetricsConstLabels,
+			prometheus.WrapRegistererWithPrefix(opts.MetricsPrefix, registry),
+		),
+	}, nil
+}
+
+// registerExporterCollectors registers go runtime, process and build info collectors enabled by options
+func registerExporterCollectors(registerer prometheus.Registerer, opts *options) {
+	if opts.MetricsGoCollector {
+		registerer.MustRegister(collectors.NewGoCollector())
+	}
+
+	if opts.MetricsProcessCollector {
+		registerer.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	if opts.MetricsBuildInfo {
+		registerer.MustRegister(version.NewBuildInfoCollector())
+	}
+}
+
+func createMetricsServer(
+	logger *zap.Logger,
+	gatherer prometheus.Gatherer,
+	registerer prometheus.Registerer,
+	opts *options,
+) (*http.Server, error) {
+	gatherer, err := resolveGatherer(gatherer, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	r := mux.NewRouter()
-	r.Handle("/metrics", promhttp.Handler())
+	r.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		registerer,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}),
+	))
 
-	return &http.Server{
+	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", opts.MetricsSrvPort),
 		Handler: r,
 	}
+
+	if opts.MetricsWebConfigFile == "" {
+		return srv, nil
+	}
+
+	webConfig, err := webconfig.New(logger, opts.MetricsWebConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.Handler = webConfig.Middleware(r)
+	srv.TLSConfig = webConfig.TLSConfig()
+
+	return srv, nil
+}
+
+// resolveGatherer wraps gatherer with metric relabeling if relabel config is set
+func resolveGatherer(gatherer prometheus.Gatherer, opts *options) (prometheus.Gatherer, error) {
+	if opts.MetricsRelabelConfig == "" {
+		return gatherer, nil
+	}
+
+	cfg, err := relabel.LoadConfig(opts.MetricsRelabelConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return relabel.NewGatherer(gatherer, cfg), nil
 }
 
-func createWebhookServer(logger *zap.Logger, registerer prometheus.Registerer, opts *options) *http.Server {
-	return &http.Server{
-		Addr:    fmt.Sprintf(":%d", opts.WebhookSrvPort),
-		Handler: setupWebhookHTTPHandler(logger, registerer, opts),
+func createWebhookRelay(logger *zap.Logger, registerer prometheus.Registerer, opts *options) (*relay.Relay, error) {
+	cfg, err := relay.LoadConfig(opts.WebhookRelayConfig)
+	if err != nil {
+		return nil, err
 	}
+
+	return relay.New(logger, cfg, registerer)
 }
 
-func setupWebhookHTTPHandler(logger *zap.Logger, registerer prometheus.Registerer, opts *options) http.Handler {
-	serveMux := mux.NewRouter()
-	serveMux.Use(
-		middleware.HTTPPrometheusMetrics(registerer),
-	)
+func createWebhookHandler(
+	logger *zap.Logger,
+	registerer prometheus.Registerer,
+	opts *options,
+	labelPolicy *labelpolicy.Policy,
+	priorityNames *collector.PriorityNames,
+	limiter *cardinality.Limiter,
+	forwarder httphandler.EventForwarder,
+) *httphandler.WebhookHandler {
+	var priorityNameResolver webhook.PriorityNameResolver
+	if opts.IncidentWebhookPriorityName {
+		priorityNameResolver = priorityNames
+	}
 
-	incidentListener := webhook.NewIncidentMetricsListener(opts.DTFormat, registerer)
+	incidentListener := webhook.NewIncidentMetricsListener(opts.DTFormat, labelPolicy, priorityNameResolver, limiter, registerer)
 
-	webhookHandler := httphandler.NewWebhookHandler(
+	return httphandler.NewWebhookHandler(
 		logger,
 		incidentListener,
 		[]byte(opts.IncidentWebhookSignatureSecret),
+		optionalSecret(opts.IncidentWebhookV2SignatureSecret),
+		httphandler.WebhookQueueOptions{
+			Size:    opts.WebhookQueueSize,
+			Workers: opts.WebhookWorkers,
+		},
+		forwarder,
+		registerer,
+	)
+}
+
+func createWebhookServer(
+	logger *zap.Logger,
+	registerer prometheus.Registerer,
+	opts *options,
+	webhookHandler *httphandler.WebhookHandler,
+) (*http.Server, error) {
+	tlsCfg := tlsconfig.Config{
+		CertFile:     opts.WebhookTLSCertFile,
+		KeyFile:      opts.WebhookTLSKeyFile,
+		ClientCAFile: opts.WebhookTLSClientCAFile,
+	}
+
+	if err := tlsCfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var tlsReloader *tlsconfig.Reloader
+
+	if tlsCfg.Enabled() {
+		var err error
+
+		tlsReloader, err = tlsconfig.NewReloader(logger, tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	handler, err := setupWebhookHTTPHandler(registerer, opts, webhookHandler, tlsReloader)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", opts.WebhookSrvPort),
+		Handler:           handler,
+		ReadHeaderTimeout: opts.WebhookReadHeaderTimeout,
+		ReadTimeout:       opts.WebhookReadTimeout,
+		WriteTimeout:      opts.WebhookWriteTimeout,
+		IdleTimeout:       opts.WebhookIdleTimeout,
+	}
+
+	if tlsReloader != nil {
+		srv.TLSConfig = tlsReloader.TLSConfig()
+	}
+
+	return srv, nil
+}
+
+func setupWebhookHTTPHandler(
+	registerer prometheus.Registerer,
+	opts *options,
+	webhookHandler *httphandler.WebhookHandler,
+	tlsReloader *tlsconfig.Reloader,
+) (http.Handler, error) {
+	serveMux := mux.NewRouter()
+	serveMux.Use(
+		middleware.HTTPPrometheusMetrics(registerer),
 	)
+
+	trustedProxies, err := middleware.ParseCIDRs(opts.WebhookTrustedProxies)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse webhook trusted proxies")
+	}
+
+	if len(opts.WebhookAllowedCIDRs) > 0 {
+		allowed, err := middleware.ParseCIDRs(opts.WebhookAllowedCIDRs)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse webhook allowed cidrs")
+		}
+
+		serveMux.Use(middleware.SourceIPAllowlist(allowed, trustedProxies))
+	}
+
+	if tlsReloader != nil && tlsReloader.ClientCertRequired() {
+		serveMux.Use(middleware.ClientCertRequired())
+	}
+
+	if opts.WebhookRateLimit > 0 {
+		serveMux.Use(middleware.RateLimit(opts.WebhookRateLimit, opts.WebhookRateLimitBurst, trustedProxies))
+	}
+
+	if opts.WebhookMaxConcurrentRequests > 0 {
+		serveMux.Use(middleware.ConcurrencyLimit(opts.WebhookMaxConcurrentRequests))
+	}
+
+	if opts.WebhookMaxBodySize > 0 {
+		serveMux.Use(middleware.MaxBodySize(opts.WebhookMaxBodySize))
+	}
+
 	webhookHandler.InstallRoutes(serveMux, opts.IncidentWebhookPath)
 
+	if opts.IncidentWebhookV2Path != "" {
+		webhookHandler.InstallV2Routes(serveMux, opts.IncidentWebhookV2Path)
+	}
+
 	recovery := gorillahandlers.RecoveryHandler(gorillahandlers.PrintRecoveryStack(true))
 
-	return recovery(serveMux)
+	return recovery(serveMux), nil
+}
+
+// listenAndServe serves TLS if the server has TLS config with certificates resolved per connection
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+
+	return srv.ListenAndServe()
 }
 
 func resolvePagerdutyMetricCollectors(
 	logger *zap.Logger,
 	registerer prometheus.Registerer,
+	limiter *cardinality.Limiter,
+	priorityNames *collector.PriorityNames,
+	graceful bool,
 	opts *options,
-) ([]collector.Interface, error) {
-	serviceAnalyticsCollectors := make([]collector.Interface, len(opts.AnalyticsReportPeriods))
-
-	collectProcessMetrics := collector.RegisterCollectProcessMetrics(registerer)
+) ([]*collector.PeriodicCollector, error) {
+	var collectors []*collector.PeriodicCollector
 
-	serviceMetricNames, err := resolveReportMetricNames(opts)
+	labelPolicy, err := resolveLabelPolicy(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	serviceAnalyticMetrics := collector.RegisterServiceAnalyticMetricsFromNames(registerer, serviceMetricNames)
+	collectProcessMetrics := collector.RegisterCollectProcessMetrics(registerer)
+
+	// one-shot collections return collection errors, periodic collections go on after failures
+	withMetrics := func(name string, c collector.Interface) collector.Interface {
+		if graceful {
+			return collector.NewGracefulCollectorWithMetrics(logger, collectProcessMetrics, name, c)
+		}
+
+		return collector.NewCollectorWithMetrics(logger, collectProcessMetrics, name, c)
+	}
 
 	pdExtendedClient := pagerduty.NewExtendedClient(opts.PagerdutyAuthToken)
 
-	for i := range opts.AnalyticsReportPeriods {
-		serviceAnalyticsCollectors[i] = collector.NewGracefulCollectorWithMetrics(
-			logger,
-			collectProcessMetrics,
-			"service_analytics",
-			collector.NewServiceAnalyticsCollector(
-				logger,
+	if opts.AnalyticsScrape {
+		serviceAnalyticsCollectors := make([]collector.Interface, len(opts.AnalyticsReportPeriods))
+
+		serviceMetricNames, err := resolveReportMetricNames(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceAnalyticMetrics := collector.RegisterServiceAnalyticMetricsFromNames(registerer, labelPolicy, serviceMetricNames)
+
+		for i := range opts.AnalyticsReportPeriods {
+			serviceAnalyticsCollectors[i] = withMetrics(
+				"service_analytics",
+				collector.NewServiceAnalyticsCollector(
+					logger,
+					pdExtendedClient,
+					labelPolicy,
+					serviceAnalyticMetrics,
+					serviceMetricNames,
+					opts.AnalyticsReportPeriods[i],
+				),
+			)
+		}
+
+		collectors = append(collectors, collector.NewPeriodicCollector(
+			opts.AnalyticsScrapeInterval,
+			serviceAnalyticsCollectors...,
+		))
+	}
+
+	newPeriodicCollector := func(interval time.Duration, name string, c collector.Interface) *collector.PeriodicCollector {
+		return collector.NewPeriodicCollector(
+			interval,
+			withMetrics(name, c),
+		)
+	}
+
+	// services and users are listed by several collectors, the caches list them once per scrape cycle
+	servicesCache := collector.NewServicesCache(pdExtendedClient)
+	usersCache := collector.NewUsersCache(pdExtendedClient)
+
+	if opts.UsersScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.UsersScrapeInterval,
+			"users",
+			collector.NewUsersCollector(usersCache, labelPolicy, limiter, registerer),
+		))
+	}
+
+	if opts.ServicesScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.ServicesScrapeInterval,
+			"services",
+			collector.NewServicesCollector(logger, pdExtendedClient, servicesCache, registerer),
+		))
+	}
+
+	if opts.EscalationPoliciesScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.EscalationPoliciesScrapeInterval,
+			"escalation_policies",
+			collector.NewEscalationPoliciesCollector(pdExtendedClient, registerer),
+		))
+	}
+
+	if opts.PrioritiesScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.PrioritiesScrapeInterval,
+			"priorities",
+			collector.NewPrioritiesCollector(pdExtendedClient, priorityNames, registerer),
+		))
+	}
+
+	if opts.TeamsScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.TeamsScrapeInterval,
+			"teams",
+			collector.NewTeamsCollector(pdExtendedClient, registerer),
+		))
+	}
+
+	if opts.MaintenanceWindowsScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.MaintenanceWindowsScrapeInterval,
+			"maintenance_windows",
+			collector.NewMaintenanceWindowsCollector(logger, pdExtendedClient, servicesCache, registerer),
+		))
+	}
+
+	if opts.BusinessServicesScrape {
+		collectors = append(collectors, newPeriodicCollector(
+			opts.BusinessServicesScrapeInterval,
+			"business_services",
+			collector.NewBusinessServicesCollector(
 				pdExtendedClient,
-				serviceAnalyticMetrics,
-				serviceMetricNames,
-				opts.AnalyticsReportPeriods[i],
+				servicesCache,
+				opts.BusinessServicesDependenciesInterval,
+				registerer,
 			),
-		)
+		))
 	}
 
-	serviceAnalyticsCollector := collector.NewPeriodicCollector(
-		opts.AnalyticsScrapeInterval,
-		serviceAnalyticsCollectors...,
-	)
+	if opts.LogEntriesScrape {
+		logEntriesCollector, err := collector.NewLogEntriesCollector(
+			logger,
+			pdExtendedClient,
+			opts.LogEntriesStateFile,
+			opts.LogEntriesLookBack,
+			limiter,
+			registerer,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "create log entries collector")
+		}
+
+		collectors = append(collectors, newPeriodicCollector(
+			opts.LogEntriesScrapeInterval,
+			"log_entries",
+			logEntriesCollector,
+		))
+	}
 
-	usersCollector := collector.NewPeriodicCollector(
-		opts.UsersScrapeInterval,
-		collector.NewGracefulCollectorWithMetrics(
+	if opts.NotificationsScrape {
+		notificationsCollector, err := collector.NewNotificationsCollector(
 			logger,
-			collectProcessMetrics,
-			"users",
-			collector.NewUsersCollector(pdExtendedClient, registerer),
-		),
-	)
+			pdExtendedClient,
+			usersCache,
+			opts.NotificationsWindow,
+			opts.NotificationsNightStartHour,
+			opts.NotificationsNightEndHour,
+			limiter,
+			registerer,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "create notifications collector")
+		}
+
+		collectors = append(collectors, newPeriodicCollector(
+			opts.NotificationsScrapeInterval,
+			"notifications",
+			notificationsCollector,
+		))
+	}
 
-	return []collector.Interface{serviceAnalyticsCollector, usersCollector}, nil
+	return collectors, nil
 }
 
 func resolveReportMetricNames(opts *options) ([]pagerduty.ReportMetricName, error) {
@@ -271,6 +854,53 @@ func resolveReportMetricNames(opts *options) ([]pagerduty.ReportMetricName, erro
 	return rm, nil
 }
 
+func createSeriesLimiter(logger *zap.Logger, registerer prometheus.Registerer, opts *options) (*cardinality.Limiter, error) {
+	limiter, err := cardinality.NewLimiter(
+		logger,
+		opts.MetricsMaxSeriesPerFamily,
+		cardinality.Action(opts.MetricsSeriesLimitAction),
+		registerer,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create series limiter")
+	}
+
+	return limiter, nil
+}
+
+// descriptiveLabels are stripped from value metrics by --metrics-strip-descriptive-labels,
+// they can be joined from info metrics by ids
+var descriptiveLabels = []string{"service_name", "service_summary", "team_summary"}
+
+func resolveLabelPolicy(opts *options) (*labelpolicy.Policy, error) {
+	var rules []string
+
+	if opts.MetricsStripDescriptiveLabels {
+		for _, l := range descriptiveLabels {
+			rules = append(rules, l+"="+string(labelpolicy.ActionDrop))
+		}
+	}
+
+	// explicit rules override the stripped labels
+	rules = append(rules, opts.LabelPolicy...)
+
+	policy, err := labelpolicy.New(rules, []byte(opts.LabelPolicyHashKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse label policy")
+	}
+
+	return policy, nil
+}
+
+// optionalSecret returns nil for empty secret so signature verification is skipped
+func optionalSecret(secret string) []byte {
+	if secret == "" {
+		return nil
+	}
+
+	return []byte(secret)
+}
+
 func createLogger(debug bool) (*zap.Logger, error) {
 	if debug {
 		return zap.NewDevelopment()
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/collector"
+)
+
+const (
+	dumpFormatText        = "text"
+	dumpFormatOpenMetrics = "openmetrics"
+	dumpFormatJSON        = "json"
+)
+
+type jsonMetricFamily struct {
+	Name    string       `json:"name"`
+	Help    string       `json:"help,omitempty"`
+	Type    string       `json:"type"`
+	Metrics []jsonMetric `json:"metrics"`
+}
+
+type jsonMetric struct {
+	Labels    map[string]string  `json:"labels,omitempty"`
+	Value     *float64           `json:"value,omitempty"`
+	Count     *uint64            `json:"count,omitempty"`
+	Sum       *float64           `json:"sum,omitempty"`
+	Buckets   map[string]uint64  `json:"buckets,omitempty"`
+	Quantiles map[string]float64 `json:"quantiles,omitempty"`
+}
+
+func newDumpCommand() *cobra.Command {
+	var (
+		o      options
+		format string
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:          "dump",
+		Short:        "Runs enabled collectors once and writes collected metrics",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger, err := prepareOptions(&o)
+			if err != nil {
+				return err
+			}
+
+			if format != dumpFormatText && format != dumpFormatOpenMetrics && format != dumpFormatJSON {
+				return fmt.Errorf("unknown dump format %s", format)
+			}
+
+			// dump must not advance the cursor of the running exporter, so log entries are counted
+			// in the look back window with the cursor kept in memory
+			o.LogEntriesStateFile = ""
+
+			registry := prometheus.NewRegistry()
+
+			registerer, err := wrapRegisterer(registry, &o)
+			if err != nil {
+				return err
+			}
+
+			limiter, err := createSeriesLimiter(logger, registerer, &o)
+			if err != nil {
+				return err
+			}
+
+			collectors, err := resolvePagerdutyMetricCollectors(logger, registerer, limiter, collector.NewPriorityNames(), false, &o)
+			if err != nil {
+				return errors.Wrap(err, "resolve metric collectors")
+			}
+
+			if err := registerer.Err(); err != nil {
+				return err
+			}
+
+			eg, gCtx := errgroup.WithContext(cmd.Context())
+
+			for i := range collectors {
+				cl := collectors[i]
+				eg.Go(func() error {
+					return cl.CollectOnce(gCtx)
+				})
+			}
+
+			if err := eg.Wait(); err != nil {
+				return errors.Wrap(err, "collect metrics")
+			}
+
+			gatherer, err := resolveGatherer(registry, &o)
+			if err != nil {
+				return err
+			}
+
+			mfs, err := gatherer.Gather()
+			if err != nil {
+				return errors.Wrap(err, "gather metrics")
+			}
+
+			if output == "" {
+				return writeMetricFamilies(cmd.OutOrStdout(), format, mfs)
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return errors.Wrap(err, "create output file")
+			}
+
+			if err := writeMetricFamilies(f, format, mfs); err != nil {
+				_ = f.Close()
+				return err
+			}
+
+			if err := f.Close(); err != nil {
+				return errors.Wrap(err, "close output file")
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&format, "format", dumpFormatText, "output format: text, openmetrics or json")
+	flags.StringVarP(&output, "output", "o", "", "output file, stdout if empty")
+	flags.DurationVar(
+		&o.LogEntriesLookBack,
+		"log-entries-look-back",
+		time.Hour,
+		"log entries created in the window before the dump are counted, the log entries state file is not used",
+	)
+
+	addCollectorFlags(flags, &o)
+
+	return cmd
+}
+
+func writeMetricFamilies(w io.Writer, format string, mfs []*dto.MetricFamily) error {
+	if format == dumpFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(metricFamiliesToJSON(mfs))
+	}
+
+	expFormat := expfmt.FmtText
+	if format == dumpFormatOpenMetrics {
+		expFormat = expfmt.FmtOpenMetrics
+	}
+
+	enc := expfmt.NewEncoder(w, expFormat)
+
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return errors.Wrap(err, "encode metric family")
+		}
+	}
+
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func metricFamiliesToJSON(mfs []*dto.MetricFamily) []jsonMetricFamily {
+	families := make([]jsonMetricFamily, len(mfs))
+
+	for i, mf := range mfs {
+		family := jsonMetricFamily{
+			Name:    mf.GetName(),
+			Help:    mf.GetHelp(),
+			Type:    jsonMetricType(mf.GetType()),
+			Metrics: make([]jsonMetric, len(mf.Metric)),
+		}
+
+		for j, m := range mf.Metric {
+			family.Metrics[j] = metricToJSON(mf.GetType(), m)
+		}
+
+		families[i] = family
+	}
+
+	return families
+}
+
+func jsonMetricType(t dto.MetricType) string {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return "counter"
+	case dto.MetricType_GAUGE:
+		return "gauge"
+	case dto.MetricType_SUMMARY:
+		return "summary"
+	case dto.MetricType_HISTOGRAM:
+		return "histogram"
+	}
+
+	return "untyped"
+}
+
+func metricToJSON(t dto.MetricType, m *dto.Metric) jsonMetric {
+	var jm jsonMetric
+
+	if len(m.Label) > 0 {
+		jm.Labels = make(map[string]string, len(m.Label))
+		for _, lp := range m.Label {
+			jm.Labels[lp.GetName()] = lp.GetValue()
+		}
+	}
+
+	switch t {
+	case dto.MetricType_COUNTER:
+		v := m.GetCounter().GetValue()
+		jm.Value = &v
+	case dto.MetricType_GAUGE:
+		v := m.GetGauge().GetValue()
+		jm.Value = &v
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		count, sum := s.GetSampleCount(), s.GetSampleSum()
+		jm.Count, jm.Sum = &count, &sum
+		jm.Quantiles = make(map[string]float64, len(s.Quantile))
+		for _, q := range s.Quantile {
+			jm.Quantiles[strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)] = q.GetValue()
+		}
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		count, sum := h.GetSampleCount(), h.GetSampleSum()
+		jm.Count, jm.Sum = &count, &sum
+		jm.Buckets = make(map[string]uint64, len(h.Bucket))
+		for _, b := range h.Bucket {
+			jm.Buckets[strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)] = b.GetCumulativeCount()
+		}
+	default:
+		v := m.GetUntyped().GetValue()
+		jm.Value = &v
+	}
+
+	return jm
+}
@@ -1,44 +1,70 @@
 package httphandler
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"github.com/24el/pagerduty-prometheus-exporter/cmd/pagerduty-prometheus-exporter/cmd/middleware"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
 )
 
-const signatureHeader = "X-PagerDuty-Signature"
-
 var errInvalidSignature = errors.New("invalid signature")
 
 type IncidentListener interface {
 	IncidentEventTriggered(event pagerduty.WebhookV3Event) error
 }
 
+// EventForwarder receives payloads of accepted events to forward them further
+type EventForwarder interface {
+	Forward(eventType pagerduty.WebhookEventType, payload []byte)
+}
+
 type WebhookHandler struct {
-	logger           *zap.Logger
-	incidentListener IncidentListener
-	signatureSecret  []byte
+	logger            *zap.Logger
+	signatureSecret   []byte
+	v2SignatureSecret []byte
+	queue             *eventQueue
+	forwarder         EventForwarder
+	metrics           *webhookMetrics
+}
+
+type WebhookQueueOptions struct {
+	Size    int
+	Workers int
 }
 
-func NewWebhookHandler(logger *zap.Logger, incidentListener IncidentListener, signatureSecret []byte) *WebhookHandler {
+func NewWebhookHandler(
+	logger *zap.Logger,
+	incidentListener IncidentListener,
+	signatureSecret []byte,
+	v2SignatureSecret []byte,
+	queueOpts WebhookQueueOptions,
+	forwarder EventForwarder,
+	registerer prometheus.Registerer,
+) *WebhookHandler {
 	return &WebhookHandler{
-		logger:           logger,
-		incidentListener: incidentListener,
-		signatureSecret:  signatureSecret,
+		logger:            logger,
+		signatureSecret:   signatureSecret,
+		v2SignatureSecret: v2SignatureSecret,
+		queue:             newEventQueue(logger, incidentListener, queueOpts.Size, queueOpts.Workers, registerer),
+		forwarder:         forwarder,
+		metrics:           registerWebhookMetrics(registerer),
 	}
 }
 
+// Run processes accepted events until ctx is done
+func (h *WebhookHandler) Run(ctx context.Context) error {
+	return h.queue.run(ctx)
+}
+
 func (h *WebhookHandler) InstallRoutes(r *mux.Router, incidentWebhookV3URL string) {
 	r.Path(incidentWebhookV3URL).
 		Methods(http.MethodPost).
@@ -46,16 +72,32 @@ func (h *WebhookHandler) InstallRoutes(r *mux.Router, incidentWebhookV3URL strin
 		HandlerFunc(h.incidentWebhookV3)
 }
 
+// InstallV2Routes installs legacy v2 webhook route, v2 messages are converted into v3 events
+func (h *WebhookHandler) InstallV2Routes(r *mux.Router, incidentWebhookV2URL string) {
+	r.Path(incidentWebhookV2URL).
+		Methods(http.MethodPost).
+		Name("incident_webhook_v2").
+		HandlerFunc(h.incidentWebhookV2)
+}
+
 func (h *WebhookHandler) incidentWebhookV3(w http.ResponseWriter, r *http.Request) {
-	rb, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+	rb, ok := h.readBody(w, r)
+	if !ok {
 		return
 	}
 
 	h.logger.Debug("incident webhook v3 event received", zap.ByteString("body", rb))
 
-	if err := h.verifySignature(rb, r); err != nil {
+	var webhookV3 pagerduty.WebhookV3
+
+	unmarshalErr := json.Unmarshal(rb, &webhookV3)
+
+	eventType := eventTypeLabel(webhookV3.Event.EventType)
+
+	h.metrics.received(eventType)
+
+	if err := h.verifySignature(h.signatureSecret, rb, r); err != nil {
+		h.metrics.rejected(eventType, rejectReasonSignature)
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
@@ -64,36 +106,63 @@ func (h *WebhookHandler) incidentWebhookV3(w http.ResponseWriter, r *http.Reques
 		h.logger.Error("body close error", zap.Error(err))
 	}
 
-	var webhookV3 pagerduty.WebhookV3
-
-	if err := json.Unmarshal(rb, &webhookV3); err != nil {
-		h.logger.Error("unmarshal webhook", zap.Error(err))
+	if unmarshalErr != nil {
+		h.logger.Error("unmarshal webhook", zap.Error(unmarshalErr))
+		h.metrics.rejected(eventType, rejectReasonPayload)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	if err := h.incidentListener.IncidentEventTriggered(webhookV3.Event); err != nil {
-		h.logger.Error("handle webhook", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+	if webhookV3.Event.EventType == pagerduty.PingEventType {
+		h.logger.Info("webhook ping event received", zap.String("event_id", webhookV3.Event.ID))
+		h.metrics.accepted(eventType)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !h.queue.enqueue(webhookV3.Event) {
+		h.logger.Warn("webhook queue is full, event dropped", zap.String("event_id", webhookV3.Event.ID))
+		h.metrics.rejected(eventType, rejectReasonQueueFull)
+		w.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if h.forwarder != nil {
+		h.forwarder.Forward(webhookV3.Event.EventType, rb)
+	}
+
+	h.metrics.accepted(eventType)
+	w.WriteHeader(http.StatusAccepted)
 }
 
-func (h *WebhookHandler) verifySignature(reqPayload []byte, req *http.Request) error {
-	if h.signatureSecret == nil {
-		return nil
+// readBody reads request body, the request is rejected if the body can't be read
+func (h *WebhookHandler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	rb, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		return rb, true
+	}
+
+	if errors.Is(err, middleware.ErrBodyTooLarge) {
+		h.metrics.rejected(unknownEventTypeLabel, rejectReasonBodyTooLarge)
+		middleware.RejectBodyTooLarge(w, r)
+
+		return nil, false
 	}
 
-	mac := hmac.New(sha256.New, h.signatureSecret)
-	if _, err := mac.Write(reqPayload); err != nil {
-		return err
+	h.metrics.rejected(unknownEventTypeLabel, rejectReasonReadBody)
+	w.WriteHeader(http.StatusBadRequest)
+
+	return nil, false
+}
+
+func (h *WebhookHandler) verifySignature(secret []byte, reqPayload []byte, req *http.Request) error {
+	if secret == nil {
+		return nil
 	}
 
-	expSignature := fmt.Sprintf("v1=%s", hex.EncodeToString(mac.Sum(nil)))
+	expSignature := pagerduty.SignWebhookPayload(secret, reqPayload)
 
-	signature := req.Header.Get(signatureHeader)
+	signature := req.Header.Get(pagerduty.WebhookSignatureHeader)
 	if signature == "" {
 		return errInvalidSignature
 	}
@@ -107,7 +176,7 @@ func (h *WebhookHandler) verifySignature(reqPayload []byte, req *http.Request) e
 	}
 
 	h.logger.Debug(
-		"incident webhook v3 sign verify failed",
+		"incident webhook sign verify failed",
 		zap.String("expected_signature", expSignature),
 		zap.String("signature", signature),
 	)
@@ -0,0 +1,92 @@
+package httphandler
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const unknownEventTypeLabel = "unknown"
+
+const (
+	rejectReasonReadBody     = "read_body"
+	rejectReasonBodyTooLarge = "body_too_large"
+	rejectReasonSignature    = "signature"
+	rejectReasonPayload      = "payload"
+	rejectReasonQueueFull    = "queue_full"
+)
+
+type webhookMetrics struct {
+	eventsReceivedCounter *prometheus.CounterVec
+	eventsAcceptedCounter *prometheus.CounterVec
+	eventsRejectedCounter *prometheus.CounterVec
+	lastEventTimestamp    prometheus.Gauge
+}
+
+func registerWebhookMetrics(registerer prometheus.Registerer) *webhookMetrics {
+	m := &webhookMetrics{
+		eventsReceivedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_webhook_events_received_total",
+				Help: "The number of received webhook events.",
+			},
+			[]string{"event_type"},
+		),
+		eventsAcceptedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_webhook_events_accepted_total",
+				Help: "The number of accepted webhook events.",
+			},
+			[]string{"event_type"},
+		),
+		eventsRejectedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_webhook_events_rejected_total",
+				Help: "The number of rejected webhook events by reason.",
+			},
+			[]string{"event_type", "reason"},
+		),
+		lastEventTimestamp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_webhook_last_event_timestamp_seconds",
+				Help: "The timestamp of the last accepted webhook event.",
+			},
+		),
+	}
+
+	registerer.MustRegister(
+		m.eventsReceivedCounter,
+		m.eventsAcceptedCounter,
+		m.eventsRejectedCounter,
+		m.lastEventTimestamp,
+	)
+
+	return m
+}
+
+func (m *webhookMetrics) received(eventType string) {
+	m.eventsReceivedCounter.WithLabelValues(eventType).Inc()
+}
+
+// accepted counts the event decoded and enqueued, only accepted events move the last event timestamp,
+// so signed but malformed payloads don't hide a broken webhook delivery
+func (m *webhookMetrics) accepted(eventType string) {
+	m.eventsAcceptedCounter.WithLabelValues(eventType).Inc()
+	m.lastEventTimestamp.Set(float64(time.Now().UnixNano()) / float64(time.Second))
+}
+
+func (m *webhookMetrics) rejected(eventType, reason string) {
+	m.eventsRejectedCounter.WithLabelValues(eventType, reason).Inc()
+}
+
+// eventTypeLabel bounds event_type label values to known event types,
+// as the payload may come from unverified source
+func eventTypeLabel(eventType pagerduty.WebhookEventType) string {
+	if !eventType.IsKnown() {
+		return unknownEventTypeLabel
+	}
+
+	return string(eventType)
+}
@@ -0,0 +1,177 @@
+package httphandler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+type eventQueueMetrics struct {
+	processingLatency *prometheus.HistogramVec
+	processingErrors  *prometheus.CounterVec
+	droppedCounter    *prometheus.CounterVec
+}
+
+type queuedEvent struct {
+	event      pagerduty.WebhookV3Event
+	enqueuedAt time.Time
+}
+
+// eventQueue decouples webhook acceptance from listener processing,
+// validated events are buffered and drained by the worker pool
+type eventQueue struct {
+	logger   *zap.Logger
+	listener IncidentListener
+	workers  int
+	events   chan queuedEvent
+
+	// enqueueMu serializes producers, so free capacity checked for a batch is not taken by others
+	enqueueMu sync.Mutex
+
+	metrics eventQueueMetrics
+}
+
+func newEventQueue(
+	logger *zap.Logger,
+	listener IncidentListener,
+	size int,
+	workers int,
+	registerer prometheus.Registerer,
+) *eventQueue {
+	q := &eventQueue{
+		logger:   logger,
+		listener: listener,
+		workers:  workers,
+		events:   make(chan queuedEvent, size),
+		metrics: eventQueueMetrics{
+			processingLatency: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "pagerduty_webhook_processing_duration_seconds",
+					Help:    "The latency of webhook events processing including time spent in the queue.",
+					Buckets: prometheus.DefBuckets,
+				},
+				[]string{"event_type"},
+			),
+			processingErrors: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "pagerduty_webhook_processing_errors_total",
+					Help: "The number of webhook events failed to be processed.",
+				},
+				[]string{"event_type"},
+			),
+			droppedCounter: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "pagerduty_webhook_queue_dropped_total",
+					Help: "The number of webhook events dropped because the queue is full.",
+				},
+				[]string{"event_type"},
+			),
+		},
+	}
+
+	registerer.MustRegister(
+		q.metrics.processingLatency,
+		q.metrics.processingErrors,
+		q.metrics.droppedCounter,
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_webhook_queue_depth",
+				Help: "The number of webhook events waiting for processing.",
+			},
+			func() float64 { return float64(len(q.events)) },
+		),
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_webhook_queue_capacity",
+				Help: "The maximum number of webhook events waiting for processing.",
+			},
+			func() float64 { return float64(cap(q.events)) },
+		),
+	)
+
+	return q
+}
+
+// enqueue returns false if the queue is full and the event was dropped
+func (q *eventQueue) enqueue(event pagerduty.WebhookV3Event) bool {
+	return q.enqueueAll([]pagerduty.WebhookV3Event{event})
+}
+
+// enqueueAll enqueues either all events or none of them,
+// it returns false if the queue has no room for all events and they were dropped
+func (q *eventQueue) enqueueAll(events []pagerduty.WebhookV3Event) bool {
+	q.enqueueMu.Lock()
+	defer q.enqueueMu.Unlock()
+
+	if cap(q.events)-len(q.events) < len(events) {
+		for _, event := range events {
+			q.metrics.droppedCounter.WithLabelValues(eventTypeLabel(event.EventType)).Inc()
+		}
+
+		return false
+	}
+
+	enqueuedAt := time.Now()
+
+	for _, event := range events {
+		q.events <- queuedEvent{event: event, enqueuedAt: enqueuedAt}
+	}
+
+	return true
+}
+
+// run processes queued events until ctx is done, then drains events left in the queue
+func (q *eventQueue) run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					q.drain()
+					return
+				case qe := <-q.events:
+					q.process(qe)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (q *eventQueue) drain() {
+	for {
+		select {
+		case qe := <-q.events:
+			q.process(qe)
+		default:
+			return
+		}
+	}
+}
+
+func (q *eventQueue) process(qe queuedEvent) {
+	eventType := eventTypeLabel(qe.event.EventType)
+
+	defer func() {
+		q.metrics.processingLatency.WithLabelValues(eventType).Observe(time.Since(qe.enqueuedAt).Seconds())
+	}()
+
+	if err := q.listener.IncidentEventTriggered(qe.event); err != nil {
+		q.metrics.processingErrors.WithLabelValues(eventType).Inc()
+		q.logger.Error("handle webhook", zap.Error(err), zap.String("event_id", qe.event.ID))
+	}
+}
@@ -0,0 +1,105 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const rejectReasonUnsupported = "unsupported"
+
+func (h *WebhookHandler) incidentWebhookV2(w http.ResponseWriter, r *http.Request) {
+	rb, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	h.logger.Debug("incident webhook v2 event received", zap.ByteString("body", rb))
+
+	if err := r.Body.Close(); err != nil {
+		h.logger.Error("body close error", zap.Error(err))
+	}
+
+	var webhookV2 pagerduty.WebhookV2
+
+	if err := json.Unmarshal(rb, &webhookV2); err != nil {
+		h.logger.Error("unmarshal webhook v2", zap.Error(err))
+		h.metrics.received(unknownEventTypeLabel)
+		h.metrics.rejected(unknownEventTypeLabel, rejectReasonPayload)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	eventTypes := make([]string, len(webhookV2.Messages))
+
+	for i, msg := range webhookV2.Messages {
+		eventType, _ := msg.V3EventType()
+		eventTypes[i] = eventTypeLabel(eventType)
+
+		h.metrics.received(eventTypes[i])
+	}
+
+	if err := h.verifySignature(h.v2SignatureSecret, rb, r); err != nil {
+		for _, eventType := range eventTypes {
+			h.metrics.rejected(eventType, rejectReasonSignature)
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var (
+		events          []pagerduty.WebhookV3Event
+		eventTypeLabels []string
+	)
+
+	for i, msg := range webhookV2.Messages {
+		event, ok := msg.ToV3Event()
+		if !ok {
+			h.logger.Debug("unsupported webhook v2 event", zap.String("event", msg.Event))
+			h.metrics.rejected(eventTypes[i], rejectReasonUnsupported)
+			continue
+		}
+
+		events = append(events, event)
+		eventTypeLabels = append(eventTypeLabels, eventTypes[i])
+	}
+
+	// messages are enqueued all at once, so a retried batch is not counted twice
+	if !h.queue.enqueueAll(events) {
+		h.logger.Warn("webhook queue is full, events dropped", zap.Int("events", len(events)))
+
+		for _, eventType := range eventTypeLabels {
+			h.metrics.rejected(eventType, rejectReasonQueueFull)
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.forwarder != nil {
+		h.forwardV2Events(events)
+	}
+
+	for _, eventType := range eventTypeLabels {
+		h.metrics.accepted(eventType)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// forwardV2Events forwards the converted events as v3 webhook payloads, so relay destinations get a single format
+func (h *WebhookHandler) forwardV2Events(events []pagerduty.WebhookV3Event) {
+	for _, event := range events {
+		payload, err := json.Marshal(pagerduty.WebhookV3{Event: event})
+		if err != nil {
+			h.logger.Error("marshal converted webhook v2 event", zap.String("event_id", event.ID), zap.Error(err))
+			continue
+		}
+
+		h.forwarder.Forward(event.EventType, payload)
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	RejectReasonBodyTooLarge     = "body_too_large"
+	RejectReasonConcurrencyLimit = "concurrency_limit"
+	RejectReasonRateLimited      = "rate_limited"
+)
+
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// ErrBodyTooLarge is returned by request body reader once MaxBodySize limit is exceeded
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// MaxBodySize rejects requests with declared content length over the limit and
+// limits body reads of the rest, handlers should reject on ErrBodyTooLarge with RejectBodyTooLarge
+func MaxBodySize(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				RejectBodyTooLarge(w, r)
+				return
+			}
+
+			r.Body = &maxBytesBody{ReadCloser: r.Body, remaining: limit}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RejectBodyTooLarge rejects request which body exceeds MaxBodySize limit
+func RejectBodyTooLarge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	Reject(w, r, http.StatusRequestEntityTooLarge, RejectReasonBodyTooLarge)
+}
+
+type maxBytesBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// read one byte over the limit to tell body of exactly limit size from the larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// ConcurrencyLimit rejects requests over max requests being handled at the same time
+func ConcurrencyLimit(max int) mux.MiddlewareFunc {
+	sem := make(chan struct{}, max)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			default:
+				Reject(w, r, http.StatusServiceUnavailable, RejectReasonConcurrencyLimit)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit rejects requests over rps with burst per source IP, see SourceIP for trusted proxies handling
+func RateLimit(rps float64, burst int, trustedProxies []*net.IPNet) mux.MiddlewareFunc {
+	limiters := &sourceLimiters{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*sourceLimiter),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := SourceIP(r, trustedProxies)
+			if ip == nil {
+				Reject(w, r, http.StatusForbidden, RejectReasonInvalidSource)
+				return
+			}
+
+			if !limiters.allow(ip.String(), time.Now()) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(1/rps)+1))
+				Reject(w, r, http.StatusTooManyRequests, RejectReasonRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type sourceLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type sourceLimiters struct {
+	rps   rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	limiters  map[string]*sourceLimiter
+	lastPurge time.Time
+}
+
+func (l *sourceLimiters) allow(source string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPurge) > rateLimiterIdleTTL {
+		for s, sl := range l.limiters {
+			if now.Sub(sl.lastSeen) > rateLimiterIdleTTL {
+				delete(l.limiters, s)
+			}
+		}
+
+		l.lastPurge = now
+	}
+
+	sl, ok := l.limiters[source]
+	if !ok {
+		sl = &sourceLimiter{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.limiters[source] = sl
+	}
+
+	sl.lastSeen = now
+
+	return sl.limiter.AllowN(now, 1)
+}
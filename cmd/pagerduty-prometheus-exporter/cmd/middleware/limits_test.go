@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	const limit = 8
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+
+		wantCode int
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:          "body under the limit",
+			body:          "1234",
+			contentLength: 4,
+			wantCode:      http.StatusOK,
+			wantBody:      "1234",
+		},
+		{
+			name:          "body of exactly the limit",
+			body:          "12345678",
+			contentLength: 8,
+			wantCode:      http.StatusOK,
+			wantBody:      "12345678",
+		},
+		{
+			name:          "declared content length over the limit",
+			body:          "123456789",
+			contentLength: 9,
+			wantCode:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "chunked body over the limit",
+			body:          "123456789",
+			contentLength: -1,
+			wantCode:      http.StatusRequestEntityTooLarge,
+			wantBody:      "12345678",
+			wantErr:       ErrBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				body    []byte
+				readErr error
+			)
+
+			handler := MaxBodySize(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, readErr = ioutil.ReadAll(r.Body)
+				if errors.Is(readErr, ErrBodyTooLarge) {
+					RejectBodyTooLarge(w, r)
+				}
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.ContentLength = tt.contentLength
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+
+			if string(body) != tt.wantBody {
+				t.Errorf("read body %q, want %q", body, tt.wantBody)
+			}
+
+			if !errors.Is(readErr, tt.wantErr) {
+				t.Errorf("read error = %v, want %v", readErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	const max = 2
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	handler := ConcurrencyLimit(max)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		return w.Code
+	}
+
+	var (
+		wg    sync.WaitGroup
+		codes = make([]int, max)
+	)
+
+	for i := 0; i < max; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve()
+		}(i)
+
+		<-started
+	}
+
+	if code := serve(); code != http.StatusServiceUnavailable {
+		t.Errorf("code over the limit = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("code of request %d under the limit = %d, want %d", i, code, http.StatusOK)
+		}
+	}
+
+	go func() { <-started }()
+
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("code after the requests are handled = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		remoteAddrs []string
+
+		wantCodes []int
+	}{
+		{
+			name:        "burst per source",
+			remoteAddrs: []string{"203.0.113.1:1", "203.0.113.1:2", "203.0.113.1:3"},
+			wantCodes:   []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:        "sources are limited independently",
+			remoteAddrs: []string{"203.0.113.1:1", "203.0.113.1:2", "203.0.113.2:1"},
+			wantCodes:   []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:        "malformed source",
+			remoteAddrs: []string{"unknown"},
+			wantCodes:   []int{http.StatusForbidden},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RateLimit(0.01, 2, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i, addr := range tt.remoteAddrs {
+				r := httptest.NewRequest(http.MethodPost, "/", nil)
+				r.RemoteAddr = addr
+
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+
+				if w.Code != tt.wantCodes[i] {
+					t.Errorf("code of request %d = %d, want %d", i, w.Code, tt.wantCodes[i])
+				}
+
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d is rate limited without Retry-After", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceLimitersAllow(t *testing.T) {
+	now := time.Now()
+
+	l := &sourceLimiters{
+		rps:      rate.Limit(1),
+		burst:    1,
+		limiters: make(map[string]*sourceLimiter),
+	}
+
+	if !l.allow("a", now) {
+		t.Fatal("first request is not allowed")
+	}
+
+	if l.allow("a", now) {
+		t.Error("request over the burst is allowed")
+	}
+
+	if !l.allow("a", now.Add(time.Second)) {
+		t.Error("request after the refill is not allowed")
+	}
+
+	l.allow("b", now.Add(rateLimiterIdleTTL))
+	l.allow("b", now.Add(2*rateLimiterIdleTTL))
+
+	if _, ok := l.limiters["a"]; ok {
+		t.Error("idle source limiter is not purged")
+	}
+
+	if _, ok := l.limiters["b"]; !ok {
+		t.Error("active source limiter is purged")
+	}
+}
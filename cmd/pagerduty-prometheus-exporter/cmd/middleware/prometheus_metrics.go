@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"sync"
@@ -11,6 +12,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+type rejectionContextKey struct{}
+
+type rejection struct {
+	reason string
+}
+
+// Reject writes the status code and marks the request as rejected by reason for HTTPPrometheusMetrics
+func Reject(w http.ResponseWriter, r *http.Request, code int, reason string) {
+	if rj, ok := r.Context().Value(rejectionContextKey{}).(*rejection); ok {
+		rj.reason = reason
+	}
+
+	w.WriteHeader(code)
+}
+
 func HTTPPrometheusMetrics(registerer prometheus.Registerer) mux.MiddlewareFunc {
 	var (
 		httpRequestsInflight = prometheus.NewGaugeVec(
@@ -30,10 +46,19 @@ func HTTPPrometheusMetrics(registerer prometheus.Registerer) mux.MiddlewareFunc
 			},
 			[]string{"handler", "code", "method"},
 		)
+		httpRequestsRejected = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: "http",
+				Name:      "requests_rejected_total",
+				Help:      "The number of HTTP requests rejected before handling by reason.",
+			},
+			[]string{"handler", "code", "reason"},
+		)
 	)
 
 	registerer.MustRegister(httpRequestsInflight)
 	registerer.MustRegister(httpRequestDurHistogram)
+	registerer.MustRegister(httpRequestsRejected)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -76,12 +101,19 @@ func HTTPPrometheusMetrics(registerer prometheus.Registerer) mux.MiddlewareFunc
 			httpRequestsInflight.With(prometheus.Labels{"handler": routeName}).Inc()
 			defer httpRequestsInflight.With(prometheus.Labels{"handler": routeName}).Dec()
 
+			rj := &rejection{}
+			r = r.WithContext(context.WithValue(r.Context(), rejectionContextKey{}, rj))
+
 			// Start the timer and when finishing measure the duration.
 			start := time.Now()
 			defer func() {
 				duration := time.Since(start)
 
 				httpRequestDurHistogram.With(prometheus.Labels{"handler": routeName, "code": strconv.Itoa(code), "method": r.Method}).Observe(duration.Seconds())
+
+				if rj.reason != "" {
+					httpRequestsRejected.With(prometheus.Labels{"handler": routeName, "code": strconv.Itoa(code), "reason": rj.reason}).Inc()
+				}
 			}()
 
 			next.ServeHTTP(wi, r)
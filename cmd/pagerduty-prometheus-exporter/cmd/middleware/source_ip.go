@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	RejectReasonSourceNotAllowed  = "source_not_allowed"
+	RejectReasonInvalidSource     = "invalid_source"
+	RejectReasonClientCertMissing = "client_cert_missing"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// ParseCIDRs parses CIDRs or single IP addresses
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, c := range cidrs {
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return nil, errors.Errorf("invalid ip %s", c)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cidr %s", c)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// SourceIPAllowlist rejects requests from source addresses outside of allowed networks.
+// X-Forwarded-For is honoured only for requests coming from trusted proxies.
+func SourceIPAllowlist(allowed, trustedProxies []*net.IPNet) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := SourceIP(r, trustedProxies)
+			if ip == nil {
+				Reject(w, r, http.StatusForbidden, RejectReasonInvalidSource)
+				return
+			}
+
+			if !containsIP(allowed, ip) {
+				Reject(w, r, http.StatusForbidden, RejectReasonSourceNotAllowed)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientCertRequired rejects TLS requests without verified client certificate
+func ClientCertRequired() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				Reject(w, r, http.StatusForbidden, RejectReasonClientCertMissing)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SourceIP resolves the client address walking X-Forwarded-For from the right
+// while the hops are trusted proxies, nil is returned for malformed addresses
+func SourceIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	forwardedFor := r.Header.Values(forwardedForHeader)
+
+	hops := make([]string, 0, len(forwardedFor))
+	for _, v := range forwardedFor {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return nil
+		}
+
+		ip = hop
+
+		if !containsIP(trustedProxies, ip) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
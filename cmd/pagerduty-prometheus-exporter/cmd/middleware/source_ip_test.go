@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+
+	nets, err := ParseCIDRs(cidrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return nets
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidrs []string
+
+		wantErr      bool
+		wantContains []string
+		wantExcludes []string
+	}{
+		{
+			name:         "cidr",
+			cidrs:        []string{"10.0.0.0/8"},
+			wantContains: []string{"10.1.2.3"},
+			wantExcludes: []string{"11.0.0.1"},
+		},
+		{
+			name:         "single ipv4 address",
+			cidrs:        []string{"192.168.1.1"},
+			wantContains: []string{"192.168.1.1"},
+			wantExcludes: []string{"192.168.1.2"},
+		},
+		{
+			name:         "single ipv6 address",
+			cidrs:        []string{"2001:db8::1"},
+			wantContains: []string{"2001:db8::1"},
+			wantExcludes: []string{"2001:db8::2"},
+		},
+		{
+			name:    "invalid address",
+			cidrs:   []string{"10.0.0.256"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cidr",
+			cidrs:   []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nets, err := ParseCIDRs(tt.cidrs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, ip := range tt.wantContains {
+				if !containsIP(nets, net.ParseIP(ip)) {
+					t.Errorf("%s is not in %v", ip, tt.cidrs)
+				}
+			}
+
+			for _, ip := range tt.wantExcludes {
+				if containsIP(nets, net.ParseIP(ip)) {
+					t.Errorf("%s is in %v", ip, tt.cidrs)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   []string
+		trustedProxies []string
+
+		want string
+	}{
+		{
+			name:       "remote address without trusted proxies",
+			remoteAddr: "203.0.113.1:1234",
+			want:       "203.0.113.1",
+		},
+		{
+			name:         "forwarded for is ignored from untrusted remote address",
+			remoteAddr:   "203.0.113.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			want:         "203.0.113.1",
+		},
+		{
+			name:           "forwarded for from trusted proxy",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"198.51.100.1"},
+			trustedProxies: []string{"10.0.0.0/8"},
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "rightmost untrusted hop",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"192.0.2.1, 198.51.100.1, 10.0.0.2"},
+			trustedProxies: []string{"10.0.0.0/8"},
+			want:           "198.51.100.1",
+		},
+		{
+			name:           "hops split across headers",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"192.0.2.1", "10.0.0.2"},
+			trustedProxies: []string{"10.0.0.0/8"},
+			want:           "192.0.2.1",
+		},
+		{
+			name:           "leftmost hop when all hops are trusted",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"10.0.0.3, 10.0.0.2"},
+			trustedProxies: []string{"10.0.0.0/8"},
+			want:           "10.0.0.3",
+		},
+		{
+			name:           "trusted proxy without forwarded for",
+			remoteAddr:     "10.0.0.1:1234",
+			trustedProxies: []string{"10.0.0.0/8"},
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "malformed hop",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"198.51.100.1, unknown"},
+			trustedProxies: []string{"10.0.0.0/8"},
+		},
+		{
+			name:       "malformed remote address",
+			remoteAddr: "unknown",
+		},
+		{
+			name:       "ipv6 remote address",
+			remoteAddr: "[2001:db8::1]:1234",
+			want:       "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+
+			for _, v := range tt.forwardedFor {
+				r.Header.Add(forwardedForHeader, v)
+			}
+
+			ip := SourceIP(r, mustParseCIDRs(t, tt.trustedProxies...))
+
+			if tt.want == "" {
+				if ip != nil {
+					t.Errorf("SourceIP() = %s, want nil", ip)
+				}
+
+				return
+			}
+
+			if !ip.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("SourceIP() = %s, want %s", ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+
+		wantCode int
+	}{
+		{
+			name:       "allowed source",
+			remoteAddr: "203.0.113.1:1234",
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "source outside of allowed networks",
+			remoteAddr: "198.51.100.1:1234",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:         "allowed source behind trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "203.0.113.1",
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:         "spoofed source behind trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "203.0.113.1, 198.51.100.1",
+			wantCode:     http.StatusForbidden,
+		},
+		{
+			name:         "malformed source behind trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "unknown",
+			wantCode:     http.StatusForbidden,
+		},
+	}
+
+	handler := SourceIPAllowlist(
+		mustParseCIDRs(t, "203.0.113.0/24"),
+		mustParseCIDRs(t, "10.0.0.0/8"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+
+			if tt.forwardedFor != "" {
+				r.Header.Set(forwardedForHeader, tt.forwardedFor)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const webhookSendSiteURL = "https://example.pagerduty.com"
+
+type webhookSendOptions struct {
+	URL          string
+	TemplatePath string
+	Timeout      time.Duration
+
+	EventType          string
+	IncidentID         string
+	IncidentNumber     uint
+	Title              string
+	Status             string
+	Urgency            string
+	ServiceID          string
+	ServiceSummary     string
+	EscalationPolicyID string
+	PriorityID         string
+	AssigneeIDs        []string
+	TeamIDs            []string
+	AgentID            string
+
+	NoteContent      string
+	ResponderUserID  string
+	ResponderState   string
+	Message          string
+	ConferenceNumber string
+	ConferenceURL    string
+}
+
+// webhookSendTemplateData is passed to payload templates
+type webhookSendTemplateData struct {
+	EventID    string
+	OccurredAt string
+	*webhookSendOptions
+}
+
+func newWebhookCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Webhook tools",
+	}
+
+	cmd.AddCommand(newWebhookSendCommand())
+
+	return cmd
+}
+
+func newWebhookSendCommand() *cobra.Command {
+	var (
+		o   options
+		wso webhookSendOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:          "send",
+		Short:        "Sends signed test webhook v3 event",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := prepareOptions(&o); err != nil {
+				return err
+			}
+
+			if wso.URL == "" {
+				return errors.New("url is required")
+			}
+
+			if !pagerduty.WebhookEventType(wso.EventType).IsKnown() {
+				return fmt.Errorf("unknown event type %s", wso.EventType)
+			}
+
+			payload, err := buildWebhookSendPayload(&wso)
+			if err != nil {
+				return err
+			}
+
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost, wso.URL, bytes.NewReader(payload))
+			if err != nil {
+				return errors.Wrap(err, "build request")
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "PagerDuty-Webhook/V3.0")
+
+			if o.IncidentWebhookSignatureSecret != "" {
+				req.Header.Set(
+					pagerduty.WebhookSignatureHeader,
+					pagerduty.SignWebhookPayload([]byte(o.IncidentWebhookSignatureSecret), payload),
+				)
+			}
+
+			resp, err := (&http.Client{Timeout: wso.Timeout}).Do(req)
+			if err != nil {
+				return errors.Wrap(err, "send webhook")
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return errors.Wrap(err, "read response body")
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s: %s\n", wso.EventType, wso.URL, resp.Status)
+			if len(body) > 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), string(body))
+			}
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return fmt.Errorf("unexpected response status code %d", resp.StatusCode)
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&wso.URL, "url", "", "webhook target url")
+	flags.StringVar(&o.IncidentWebhookSignatureSecret, "incident-webhook-signature-secret", "", "incident webhook signature secret")
+	flags.StringVar(&wso.TemplatePath, "template", "", "payload template file, built from flags if empty")
+	flags.DurationVar(&wso.Timeout, "timeout", 10*time.Second, "request timeout")
+	flags.StringVar(&wso.EventType, "event-type", pagerduty.IncidentTriggeredEventType, "webhook event type")
+	flags.StringVar(&wso.IncidentID, "incident-id", "PTEST01", "incident id")
+	flags.UintVar(&wso.IncidentNumber, "incident-number", 1, "incident number")
+	flags.StringVar(&wso.Title, "title", "Test incident", "incident title")
+	flags.StringVar(&wso.Status, "status", "", "incident status, resolved from event type if empty")
+	flags.StringVar(&wso.Urgency, "urgency", "high", "incident urgency")
+	flags.StringVar(&wso.ServiceID, "service-id", "PSRV001", "incident service id")
+	flags.StringVar(&wso.ServiceSummary, "service-summary", "Test service", "incident service summary")
+	flags.StringVar(&wso.EscalationPolicyID, "escalation-policy-id", "PEP0001", "incident escalation policy id")
+	flags.StringVar(&wso.PriorityID, "priority-id", "", "incident priority id")
+	flags.StringSliceVar(&wso.AssigneeIDs, "assignee-ids", []string{"PUSR001"}, "incident assignee ids")
+	flags.StringSliceVar(&wso.TeamIDs, "team-ids", nil, "incident team ids")
+	flags.StringVar(&wso.AgentID, "agent-id", "PUSR001", "event agent user id")
+	flags.StringVar(&wso.NoteContent, "note-content", "Test note", "incident note content of incident.annotated events")
+	flags.StringVar(&wso.ResponderUserID, "responder-user-id", "PUSR002", "responder user id of incident.responder events")
+	flags.StringVar(&wso.ResponderState, "responder-state", "", "responder state of incident.responder events, resolved from event type if empty")
+	flags.StringVar(&wso.Message, "message", "Test message", "message of incident.responder and incident.status_update_published events")
+	flags.StringVar(&wso.ConferenceNumber, "conference-number", "+1-555-0100,,123456#", "conference number of incident.conference_bridge.updated events")
+	flags.StringVar(&wso.ConferenceURL, "conference-url", "https://example.com/conference", "conference url of incident.conference_bridge.updated events")
+	flags.BoolVar(&o.Debug, "debug", false, "debug")
+
+	return cmd
+}
+
+func buildWebhookSendPayload(o *webhookSendOptions) ([]byte, error) {
+	eventID, err := randomWebhookEventID()
+	if err != nil {
+		return nil, err
+	}
+
+	occurredAt := time.Now().UTC()
+
+	if o.Status == "" {
+		o.Status = incidentStatusFromEventType(o.EventType)
+	}
+
+	if o.TemplatePath != "" {
+		return renderWebhookSendTemplate(o, webhookSendTemplateData{
+			EventID:            eventID,
+			OccurredAt:         occurredAt.Format(time.RFC3339Nano),
+			webhookSendOptions: o,
+		})
+	}
+
+	resourceType, data := buildWebhookSendData(o)
+
+	webhookV3 := pagerduty.WebhookV3{
+		Event: pagerduty.WebhookV3Event{
+			ID:           eventID,
+			EventType:    pagerduty.WebhookEventType(o.EventType),
+			ResourceType: resourceType,
+			OccurredAt:   occurredAt,
+			Data:         data,
+		},
+	}
+
+	if o.AgentID != "" {
+		webhookV3.Event.Agent = gopagerduty.Agent(webhookSendReference(o.AgentID, "user_reference", "users", ""))
+	}
+
+	return json.Marshal(webhookV3)
+}
+
+// buildWebhookSendData returns resource type and data of the event type built from flags
+func buildWebhookSendData(o *webhookSendOptions) (string, interface{}) {
+	incidentRef := webhookSendReference(o.IncidentID, "incident_reference", "incidents", o.Title)
+
+	switch pagerduty.WebhookEventType(o.EventType) {
+	case pagerduty.PingEventType:
+		return "pagey", map[string]string{
+			"type":    "ping",
+			"message": "Hello from your friend Pagey!",
+		}
+	case pagerduty.IncidentAnnotatedEventType:
+		return "incident", pagerduty.WebhookV3IncidentNote{
+			ID:       "PNOTE01",
+			Type:     "incident_note",
+			Incident: incidentRef,
+			Content:  o.NoteContent,
+		}
+	case pagerduty.IncidentResponderAddedEventType, pagerduty.IncidentResponderRepliedEventType:
+		state := o.ResponderState
+		if state == "" {
+			state = responderStateFromEventType(o.EventType)
+		}
+
+		return "incident", pagerduty.WebhookV3IncidentResponder{
+			Type:             "incident_responder",
+			Incident:         incidentRef,
+			User:             webhookSendReference(o.ResponderUserID, "user_reference", "users", ""),
+			EscalationPolicy: webhookSendReference(o.EscalationPolicyID, "escalation_policy_reference", "escalation_policies", ""),
+			Message:          o.Message,
+			State:            state,
+		}
+	case pagerduty.IncidentStatusUpdatePublishedEventType:
+		return "incident", pagerduty.WebhookV3IncidentStatusUpdate{
+			ID:       "PSTATUS",
+			Type:     "status_update",
+			Incident: incidentRef,
+			Sender:   webhookSendReference(o.AgentID, "user_reference", "users", ""),
+			Message:  o.Message,
+		}
+	case pagerduty.IncidentConferenceBridgeUpdatedEventType:
+		return "incident", pagerduty.WebhookV3IncidentConferenceBridge{
+			Type:             "conference_bridge",
+			Incident:         incidentRef,
+			ConferenceNumber: o.ConferenceNumber,
+			ConferenceURL:    o.ConferenceURL,
+		}
+	case pagerduty.ServiceCreatedEventType, pagerduty.ServiceUpdatedEventType, pagerduty.ServiceDeletedEventType:
+		service := pagerduty.WebhookV3Service{
+			ID:               o.ServiceID,
+			Type:             "service",
+			Self:             "https://api.pagerduty.com/services/" + o.ServiceID,
+			HTMLURL:          webhookSendSiteURL + "/services/" + o.ServiceID,
+			Summary:          o.ServiceSummary,
+			Name:             o.ServiceSummary,
+			Status:           "active",
+			EscalationPolicy: webhookSendReference(o.EscalationPolicyID, "escalation_policy_reference", "escalation_policies", ""),
+		}
+
+		for _, id := range o.TeamIDs {
+			service.Teams = append(service.Teams, webhookSendReference(id, "team_reference", "teams", ""))
+		}
+
+		return "service", service
+	}
+
+	incident := pagerduty.WebhookV3Incident{
+		Id:               o.IncidentID,
+		Number:           o.IncidentNumber,
+		Type:             "incident",
+		Title:            o.Title,
+		Status:           o.Status,
+		Urgency:          o.Urgency,
+		Self:             "https://api.pagerduty.com/incidents/" + o.IncidentID,
+		HTMLURL:          webhookSendSiteURL + "/incidents/" + o.IncidentID,
+		Service:          webhookSendReference(o.ServiceID, "service_reference", "services", o.ServiceSummary),
+		EscalationPolicy: webhookSendReference(o.EscalationPolicyID, "escalation_policy_reference", "escalation_policies", ""),
+	}
+
+	if o.PriorityID != "" {
+		incident.Priority = webhookSendReference(o.PriorityID, "priority_reference", "priorities", "")
+	}
+
+	for _, id := range o.AssigneeIDs {
+		incident.Assignees = append(incident.Assignees, webhookSendReference(id, "user_reference", "users", ""))
+	}
+
+	for _, id := range o.TeamIDs {
+		incident.Teams = append(incident.Teams, webhookSendReference(id, "team_reference", "teams", ""))
+	}
+
+	return "incident", incident
+}
+
+func renderWebhookSendTemplate(o *webhookSendOptions, data webhookSendTemplateData) ([]byte, error) {
+	tmpl, err := template.New("payload").
+		Funcs(template.FuncMap{"json": webhookSendTemplateJSON}).
+		ParseFiles(o.TemplatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.ExecuteTemplate(&buf, filepath.Base(o.TemplatePath), data); err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("rendered template is not valid json")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func webhookSendTemplateJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+
+	return string(b), err
+}
+
+func webhookSendReference(id, refType, collection, summary string) gopagerduty.APIObject {
+	if summary == "" {
+		summary = id
+	}
+
+	return gopagerduty.APIObject{
+		ID:      id,
+		Type:    refType,
+		Summary: summary,
+		Self:    fmt.Sprintf("https://api.pagerduty.com/%s/%s", collection, id),
+		HTMLURL: fmt.Sprintf("%s/%s/%s", webhookSendSiteURL, collection, id),
+	}
+}
+
+func incidentStatusFromEventType(eventType string) string {
+	switch eventType {
+	case pagerduty.IncidentAcknowledgedEventType:
+		return "acknowledged"
+	case pagerduty.IncidentResolvedEventType:
+		return "resolved"
+	}
+
+	return "triggered"
+}
+
+func responderStateFromEventType(eventType string) string {
+	if eventType == pagerduty.IncidentResponderRepliedEventType {
+		return "accepted"
+	}
+
+	return "pending"
+}
+
+func randomWebhookEventID() (string, error) {
+	b := make([]byte, 13)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate event id")
+	}
+
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	// user time zones are loaded by notifications collector, the image has no tzdata
+	_ "time/tzdata"
 
 	"github.com/24el/pagerduty-prometheus-exporter/cmd/pagerduty-prometheus-exporter/cmd"
 )
@@ -0,0 +1,228 @@
+package cardinality
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+type Action string
+
+const (
+	// ActionOverflow folds new label sets past the limit into the single series with OverflowLabelValue values
+	ActionOverflow Action = "overflow"
+	// ActionDrop drops new label sets past the limit
+	ActionDrop Action = "drop"
+)
+
+const OverflowLabelValue = "__overflow__"
+
+// Limiter limits the number of series per metric family of the vectors created by it
+type Limiter struct {
+	logger    *zap.Logger
+	maxSeries int
+	action    Action
+
+	seriesDropped *prometheus.CounterVec
+}
+
+// NewLimiter returns limiter allowing maxSeries per family, the series are not limited if maxSeries is 0.
+// With ActionOverflow one of maxSeries is reserved for the overflow series.
+func NewLimiter(logger *zap.Logger, maxSeries int, action Action, registerer prometheus.Registerer) (*Limiter, error) {
+	if maxSeries < 0 {
+		return nil, fmt.Errorf("max series must not be negative, got %d", maxSeries)
+	}
+
+	if action != ActionOverflow && action != ActionDrop {
+		return nil, fmt.Errorf("unsupported series limit action %q", action)
+	}
+
+	l := &Limiter{
+		logger:    logger,
+		maxSeries: maxSeries,
+		action:    action,
+
+		seriesDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_exporter_series_dropped_total",
+				Help: "The number of label sets dropped or folded into overflow series because of the series limit.",
+			},
+			[]string{"family"},
+		),
+	}
+
+	registerer.MustRegister(l.seriesDropped)
+
+	return l, nil
+}
+
+type GaugeVec struct {
+	*prometheus.GaugeVec
+	series *seriesSet
+}
+
+func (l *Limiter) NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *GaugeVec {
+	return &GaugeVec{
+		GaugeVec: prometheus.NewGaugeVec(opts, labelNames),
+		series:   l.newSeriesSet(opts.Name, labelNames),
+	}
+}
+
+// With returns the gauge of labels, or not exported gauge if labels are dropped by the limit
+func (v *GaugeVec) With(labels prometheus.Labels) prometheus.Gauge {
+	labels, ok := v.series.admit(labels)
+	if !ok {
+		return discardedGauge
+	}
+
+	return v.GaugeVec.With(labels)
+}
+
+// Reset deletes all gauges and frees the series limit
+func (v *GaugeVec) Reset() {
+	v.series.reset()
+	v.GaugeVec.Reset()
+}
+
+// Delete deletes the gauge of labels and frees its series
+func (v *GaugeVec) Delete(labels prometheus.Labels) bool {
+	v.series.delete(labels)
+
+	return v.GaugeVec.Delete(labels)
+}
+
+type CounterVec struct {
+	*prometheus.CounterVec
+	series *seriesSet
+}
+
+func (l *Limiter) NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *CounterVec {
+	return &CounterVec{
+		CounterVec: prometheus.NewCounterVec(opts, labelNames),
+		series:     l.newSeriesSet(opts.Name, labelNames),
+	}
+}
+
+// With returns the counter of labels, or not exported counter if labels are dropped by the limit
+func (v *CounterVec) With(labels prometheus.Labels) prometheus.Counter {
+	labels, ok := v.series.admit(labels)
+	if !ok {
+		return discardedCounter
+	}
+
+	return v.CounterVec.With(labels)
+}
+
+// Reset deletes all counters and frees the series limit
+func (v *CounterVec) Reset() {
+	v.series.reset()
+	v.CounterVec.Reset()
+}
+
+// Delete deletes the counter of labels and frees its series
+func (v *CounterVec) Delete(labels prometheus.Labels) bool {
+	v.series.delete(labels)
+
+	return v.CounterVec.Delete(labels)
+}
+
+var (
+	discardedGauge   = prometheus.NewGauge(prometheus.GaugeOpts{Name: "discarded"})
+	discardedCounter = prometheus.NewCounter(prometheus.CounterOpts{Name: "discarded"})
+)
+
+type seriesSet struct {
+	limiter    *Limiter
+	family     string
+	labelNames []string
+
+	mu     sync.Mutex
+	seen   map[string]struct{}
+	logged bool
+}
+
+func (l *Limiter) newSeriesSet(family string, labelNames []string) *seriesSet {
+	return &seriesSet{
+		limiter:    l,
+		family:     family,
+		labelNames: labelNames,
+		seen:       make(map[string]struct{}),
+	}
+}
+
+func (s *seriesSet) signature(labels prometheus.Labels) string {
+	values := make([]string, len(s.labelNames))
+	for i, n := range s.labelNames {
+		values[i] = labels[n]
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+func (s *seriesSet) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen = make(map[string]struct{})
+}
+
+func (s *seriesSet) delete(labels prometheus.Labels) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.seen, s.signature(labels))
+}
+
+// admit returns labels to be used for the series and whether the series is exported at all
+func (s *seriesSet) admit(labels prometheus.Labels) (prometheus.Labels, bool) {
+	if s.limiter.maxSeries == 0 {
+		return labels, true
+	}
+
+	signature := s.signature(labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[signature]; ok {
+		return labels, true
+	}
+
+	limit := s.limiter.maxSeries
+	if s.limiter.action == ActionOverflow {
+		// the overflow series is not kept in seen, so its slot is reserved
+		limit--
+	}
+
+	if len(s.seen) < limit {
+		s.seen[signature] = struct{}{}
+		return labels, true
+	}
+
+	s.limiter.seriesDropped.WithLabelValues(s.family).Inc()
+
+	if !s.logged {
+		s.logged = true
+		s.limiter.logger.Warn(
+			"metric family series limit reached, new label sets are not exported",
+			zap.String("family", s.family),
+			zap.Int("max_series", s.limiter.maxSeries),
+			zap.String("action", string(s.limiter.action)),
+			zap.Any("labels", labels),
+		)
+	}
+
+	if s.limiter.action == ActionDrop {
+		return nil, false
+	}
+
+	overflow := make(prometheus.Labels, len(s.labelNames))
+	for _, n := range s.labelNames {
+		overflow[n] = OverflowLabelValue
+	}
+
+	return overflow, true
+}
@@ -0,0 +1,166 @@
+package cardinality
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestSeriesSetAdmit(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxSeries int
+		action    Action
+		users     []string
+
+		wantAdmitted []string
+		wantSeries   int
+		wantDropped  float64
+	}{
+		{
+			name:         "unlimited",
+			maxSeries:    0,
+			action:       ActionOverflow,
+			users:        []string{"U1", "U2", "U3"},
+			wantAdmitted: []string{"U1", "U2", "U3"},
+			wantSeries:   3,
+		},
+		{
+			name:         "known label sets are admitted past the limit",
+			maxSeries:    2,
+			action:       ActionDrop,
+			users:        []string{"U1", "U2", "U1", "U2"},
+			wantAdmitted: []string{"U1", "U2", "U1", "U2"},
+			wantSeries:   2,
+		},
+		{
+			name:         "drop past the limit",
+			maxSeries:    2,
+			action:       ActionDrop,
+			users:        []string{"U1", "U2", "U3", "U4"},
+			wantAdmitted: []string{"U1", "U2"},
+			wantSeries:   2,
+			wantDropped:  2,
+		},
+		{
+			name:         "overflow series counts towards the limit",
+			maxSeries:    3,
+			action:       ActionOverflow,
+			users:        []string{"U1", "U2", "U3", "U4", "U1"},
+			wantAdmitted: []string{"U1", "U2", OverflowLabelValue, OverflowLabelValue, "U1"},
+			wantSeries:   3,
+			wantDropped:  2,
+		},
+		{
+			name:         "only overflow series with limit of one",
+			maxSeries:    1,
+			action:       ActionOverflow,
+			users:        []string{"U1", "U2"},
+			wantAdmitted: []string{OverflowLabelValue, OverflowLabelValue},
+			wantSeries:   1,
+			wantDropped:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := NewLimiter(zap.NewNop(), tt.maxSeries, tt.action, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := limiter.NewGaugeVec(prometheus.GaugeOpts{Name: "users"}, []string{"user_id", "team_id"})
+
+			var admitted []string
+
+			for _, user := range tt.users {
+				labels, ok := v.series.admit(prometheus.Labels{"user_id": user, "team_id": "T1"})
+				if !ok {
+					continue
+				}
+
+				admitted = append(admitted, labels["user_id"])
+				v.GaugeVec.With(labels).Set(1)
+			}
+
+			if !equalStrings(admitted, tt.wantAdmitted) {
+				t.Errorf("admitted %v, want %v", admitted, tt.wantAdmitted)
+			}
+
+			if got := testutil.CollectAndCount(v.GaugeVec); got != tt.wantSeries {
+				t.Errorf("family has %d series, want %d", got, tt.wantSeries)
+			}
+
+			if got := testutil.ToFloat64(limiter.seriesDropped.WithLabelValues("users")); got != tt.wantDropped {
+				t.Errorf("dropped %v label sets, want %v", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestGaugeVecResetAndDeleteFreeSeries(t *testing.T) {
+	tests := []struct {
+		name  string
+		free  func(v *GaugeVec)
+		users []string
+
+		wantSeries int
+	}{
+		{
+			name:       "reset",
+			free:       func(v *GaugeVec) { v.Reset() },
+			users:      []string{"U3", "U4"},
+			wantSeries: 2,
+		},
+		{
+			name:       "delete",
+			free:       func(v *GaugeVec) { v.Delete(prometheus.Labels{"user_id": "U1"}) },
+			users:      []string{"U3"},
+			wantSeries: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := NewLimiter(zap.NewNop(), 2, ActionDrop, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := limiter.NewGaugeVec(prometheus.GaugeOpts{Name: "users"}, []string{"user_id"})
+
+			v.With(prometheus.Labels{"user_id": "U1"}).Set(1)
+			v.With(prometheus.Labels{"user_id": "U2"}).Set(1)
+
+			tt.free(v)
+
+			for _, user := range tt.users {
+				v.With(prometheus.Labels{"user_id": user}).Set(1)
+			}
+
+			if got := testutil.CollectAndCount(v.GaugeVec); got != tt.wantSeries {
+				t.Errorf("family has %d series, want %d", got, tt.wantSeries)
+			}
+
+			if got := testutil.ToFloat64(limiter.seriesDropped.WithLabelValues("users")); got != 0 {
+				t.Errorf("dropped %v label sets, want 0", got)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
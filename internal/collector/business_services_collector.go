@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+var businessServiceImpactStatuses = []string{"impacted", "not_impacted"}
+
+type serviceDependency struct {
+	upstreamID   string
+	downstreamID string
+}
+
+type BusinessServicesCollector struct {
+	pdClient             pagerduty.Client
+	services             *ServicesCache
+	dependenciesInterval time.Duration
+
+	// dependencies are listed with a request per service, so the graph is listed once per dependenciesInterval
+	dependencies         map[serviceDependency]struct{}
+	dependenciesListedAt time.Time
+
+	businessServiceInfoGauge         *prometheus.GaugeVec
+	businessServiceImpactStatusGauge *prometheus.GaugeVec
+	serviceDependencyGauge           *prometheus.GaugeVec
+}
+
+// NewBusinessServicesCollector returns collector of business services listing the service dependency graph
+// once per dependenciesInterval, the graph is listed on every collection if dependenciesInterval is 0
+func NewBusinessServicesCollector(
+	pdClient pagerduty.Client,
+	services *ServicesCache,
+	dependenciesInterval time.Duration,
+	registerer prometheus.Registerer,
+) *BusinessServicesCollector {
+	c := &BusinessServicesCollector{
+		pdClient:             pdClient,
+		services:             services,
+		dependenciesInterval: dependenciesInterval,
+
+		businessServiceInfoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_business_service_info",
+				Help: "Business service info.",
+			},
+			[]string{"business_service_id", "name", "team_id"},
+		),
+		businessServiceImpactStatusGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_business_service_impact_status",
+				Help: "Business service impact status, 1 for the current status of the business service and 0 for the others.",
+			},
+			[]string{"business_service_id", "status"},
+		),
+		serviceDependencyGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_dependency",
+				Help: "Dependency of the downstream service on the supporting upstream service, business or technical.",
+			},
+			[]string{"upstream_id", "downstream_id"},
+		),
+	}
+
+	registerer.MustRegister(c.businessServiceInfoGauge, c.businessServiceImpactStatusGauge, c.serviceDependencyGauge)
+
+	return c
+}
+
+func (c *BusinessServicesCollector) Collect(ctx context.Context) error {
+	businessServices, err := c.pdClient.ListBusinessServicesPaginated(ctx, gopagerduty.ListBusinessServiceOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list business services")
+	}
+
+	impacts, err := c.pdClient.ListBusinessServiceImpacts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "list business service impacts")
+	}
+
+	if c.dependencies == nil || time.Since(c.dependenciesListedAt) >= c.dependenciesInterval {
+		services, err := c.services.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		dependencies, err := c.listDependencies(ctx, businessServices, services)
+		if err != nil {
+			return err
+		}
+
+		c.dependencies, c.dependenciesListedAt = dependencies, time.Now()
+	}
+
+	c.businessServiceInfoGauge.Reset()
+	c.businessServiceImpactStatusGauge.Reset()
+	c.serviceDependencyGauge.Reset()
+
+	for _, businessService := range businessServices {
+		var teamID string
+		if businessService.Team != nil {
+			teamID = businessService.Team.ID
+		}
+
+		c.businessServiceInfoGauge.With(prometheus.Labels{
+			"business_service_id": businessService.ID,
+			"name":                businessService.Name,
+			"team_id":             teamID,
+		}).Set(1)
+	}
+
+	for _, impact := range impacts {
+		for _, status := range businessServiceImpactStatuses {
+			var v float64
+			if status == impact.Status {
+				v = 1
+			}
+
+			c.businessServiceImpactStatusGauge.With(prometheus.Labels{
+				"business_service_id": impact.ID,
+				"status":              status,
+			}).Set(v)
+		}
+	}
+
+	for dependency := range c.dependencies {
+		c.serviceDependencyGauge.With(prometheus.Labels{
+			"upstream_id":   dependency.upstreamID,
+			"downstream_id": dependency.downstreamID,
+		}).Set(1)
+	}
+
+	return nil
+}
+
+// listDependencies lists the dependency graph edges, each edge is listed by both of its services so they are deduplicated
+func (c *BusinessServicesCollector) listDependencies(
+	ctx context.Context,
+	businessServices []*gopagerduty.BusinessService,
+	services []gopagerduty.Service,
+) (map[serviceDependency]struct{}, error) {
+	dependencies := make(map[serviceDependency]struct{})
+
+	addRelationships := func(list *gopagerduty.ListServiceDependencies) {
+		for _, r := range list.Relationships {
+			if r.SupportingService == nil || r.DependentService == nil {
+				continue
+			}
+
+			dependencies[serviceDependency{
+				upstreamID:   r.SupportingService.ID,
+				downstreamID: r.DependentService.ID,
+			}] = struct{}{}
+		}
+	}
+
+	for _, businessService := range businessServices {
+		list, err := c.pdClient.ListBusinessServiceDependenciesWithContext(ctx, businessService.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list business service %s dependencies", businessService.ID)
+		}
+
+		addRelationships(list)
+	}
+
+	for _, service := range services {
+		list, err := c.pdClient.ListTechnicalServiceDependenciesWithContext(ctx, service.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list service %s dependencies", service.ID)
+		}
+
+		addRelationships(list)
+	}
+
+	return dependencies, nil
+}
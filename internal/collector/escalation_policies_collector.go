@@ -0,0 +1,193 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const escalationPoliciesRequestLimit = 100
+
+type EscalationPoliciesCollector struct {
+	pdClient pagerduty.Client
+
+	escalationPolicyInfoGauge     *prometheus.GaugeVec
+	escalationPolicyRulesGauge    *prometheus.GaugeVec
+	escalationPolicyNumLoopsGauge *prometheus.GaugeVec
+	ruleDelayGauge                *prometheus.GaugeVec
+	ruleTargetsGauge              *prometheus.GaugeVec
+	escalationPolicyServicesGauge *prometheus.GaugeVec
+	singlePointOfFailureGauge     *prometheus.GaugeVec
+	noScheduleTargetsGauge        *prometheus.GaugeVec
+}
+
+func NewEscalationPoliciesCollector(pdClient pagerduty.Client, registerer prometheus.Registerer) *EscalationPoliciesCollector {
+	c := &EscalationPoliciesCollector{
+		pdClient: pdClient,
+
+		escalationPolicyInfoGauge: newEscalationPolicyInfoGauge(),
+		escalationPolicyRulesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_rules",
+				Help: "The number of escalation policy rules.",
+			},
+			[]string{"escalation_policy_id"},
+		),
+		escalationPolicyNumLoopsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_num_loops",
+				Help: "The number of times the escalation policy repeats after reaching the end of its escalation.",
+			},
+			[]string{"escalation_policy_id"},
+		),
+		ruleDelayGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_rule_delay_seconds",
+				Help: "Escalation delay of the escalation policy rule by the rule position.",
+			},
+			[]string{"escalation_policy_id", "rule_index"},
+		),
+		ruleTargetsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_rule_targets",
+				Help: "The number of distinct targets of the escalation policy rule by the rule position.",
+			},
+			[]string{"escalation_policy_id", "rule_index"},
+		),
+		escalationPolicyServicesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_services",
+				Help: "The number of services using the escalation policy.",
+			},
+			[]string{"escalation_policy_id"},
+		),
+		singlePointOfFailureGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_single_point_of_failure",
+				Help: "1 if all escalation policy rules target the same single user.",
+			},
+			[]string{"escalation_policy_id"},
+		),
+		noScheduleTargetsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_escalation_policy_no_schedule_targets",
+				Help: "1 if no escalation policy rule targets a schedule.",
+			},
+			[]string{"escalation_policy_id"},
+		),
+	}
+
+	registerer.MustRegister(
+		c.escalationPolicyInfoGauge,
+		c.escalationPolicyRulesGauge,
+		c.escalationPolicyNumLoopsGauge,
+		c.ruleDelayGauge,
+		c.ruleTargetsGauge,
+		c.escalationPolicyServicesGauge,
+		c.singlePointOfFailureGauge,
+		c.noScheduleTargetsGauge,
+	)
+
+	return c
+}
+
+func (c *EscalationPoliciesCollector) Collect(ctx context.Context) error {
+	policies, err := c.listEscalationPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.escalationPolicyInfoGauge.Reset()
+	c.escalationPolicyRulesGauge.Reset()
+	c.escalationPolicyNumLoopsGauge.Reset()
+	c.ruleDelayGauge.Reset()
+	c.ruleTargetsGauge.Reset()
+	c.escalationPolicyServicesGauge.Reset()
+	c.singlePointOfFailureGauge.Reset()
+	c.noScheduleTargetsGauge.Reset()
+
+	for _, policy := range policies {
+		c.setEscalationPolicyMetrics(policy)
+	}
+
+	return nil
+}
+
+func (c *EscalationPoliciesCollector) setEscalationPolicyMetrics(policy gopagerduty.EscalationPolicy) {
+	policyLabels := prometheus.Labels{"escalation_policy_id": policy.ID}
+
+	c.escalationPolicyInfoGauge.With(prometheus.Labels{
+		"escalation_policy_id": policy.ID,
+		"name":                 policy.Name,
+	}).Set(1)
+
+	c.escalationPolicyRulesGauge.With(policyLabels).Set(float64(len(policy.EscalationRules)))
+	c.escalationPolicyNumLoopsGauge.With(policyLabels).Set(float64(policy.NumLoops))
+	c.escalationPolicyServicesGauge.With(policyLabels).Set(float64(len(policy.Services)))
+
+	policyTargets := make(map[string]string)
+
+	for i, rule := range policy.EscalationRules {
+		ruleLabels := prometheus.Labels{
+			"escalation_policy_id": policy.ID,
+			"rule_index":           strconv.Itoa(i),
+		}
+
+		ruleTargets := make(map[string]struct{}, len(rule.Targets))
+
+		for _, target := range rule.Targets {
+			ruleTargets[target.ID] = struct{}{}
+			policyTargets[target.ID] = target.Type
+		}
+
+		c.ruleDelayGauge.With(ruleLabels).Set(float64(rule.Delay * 60))
+		c.ruleTargetsGauge.With(ruleLabels).Set(float64(len(ruleTargets)))
+	}
+
+	var singlePointOfFailure, noScheduleTargets float64 = 0, 1
+
+	for _, targetType := range policyTargets {
+		if isScheduleTarget(targetType) {
+			noScheduleTargets = 0
+		}
+
+		if len(policyTargets) == 1 && !isScheduleTarget(targetType) {
+			singlePointOfFailure = 1
+		}
+	}
+
+	c.singlePointOfFailureGauge.With(policyLabels).Set(singlePointOfFailure)
+	c.noScheduleTargetsGauge.With(policyLabels).Set(noScheduleTargets)
+}
+
+func isScheduleTarget(targetType string) bool {
+	return targetType == "schedule" || targetType == "schedule_reference"
+}
+
+func (c *EscalationPoliciesCollector) listEscalationPolicies(ctx context.Context) ([]gopagerduty.EscalationPolicy, error) {
+	var policies []gopagerduty.EscalationPolicy
+
+	listOpts := gopagerduty.ListEscalationPoliciesOptions{}
+	listOpts.Limit = escalationPoliciesRequestLimit
+
+	for {
+		list, err := c.pdClient.ListEscalationPoliciesWithContext(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, list.EscalationPolicies...)
+
+		listOpts.Offset += list.Limit
+		if !list.More {
+			break
+		}
+	}
+
+	return policies, nil
+}
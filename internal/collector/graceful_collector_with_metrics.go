@@ -45,7 +45,8 @@ func RegisterCollectProcessMetrics(registerer prometheus.Registerer) *CollectPro
 	}
 }
 
-type GracefulCollectorWithMetrics struct {
+// CollectorWithMetrics counts, times and logs collections of the wrapped collector, collection errors are returned
+type CollectorWithMetrics struct {
 	logger        *zap.Logger
 	collectorName string
 	collector     Interface
@@ -55,17 +56,17 @@ type GracefulCollectorWithMetrics struct {
 	collectionErrorsCounter    prometheus.Counter
 }
 
-func NewGracefulCollectorWithMetrics(
+func NewCollectorWithMetrics(
 	logger *zap.Logger,
 	metrics *CollectProcessMetrics,
 	collectorName string,
 	collector Interface,
-) *GracefulCollectorWithMetrics {
+) *CollectorWithMetrics {
 	metricLabels := prometheus.Labels{
 		"collector_name": collectorName,
 	}
 
-	c := &GracefulCollectorWithMetrics{
+	c := &CollectorWithMetrics{
 		logger:        logger,
 		collectorName: collectorName,
 		collector:     collector,
@@ -78,7 +79,7 @@ func NewGracefulCollectorWithMetrics(
 	return c
 }
 
-func (c *GracefulCollectorWithMetrics) Collect(ctx context.Context) error {
+func (c *CollectorWithMetrics) Collect(ctx context.Context) error {
 	t := time.Now()
 	defer func() {
 		c.collectionLatencyHistogram.Observe(time.Since(t).Seconds())
@@ -98,5 +99,28 @@ func (c *GracefulCollectorWithMetrics) Collect(ctx context.Context) error {
 
 	c.logger.Error("collection failed", zap.Error(err), zap.String("collector", c.collectorName))
 
+	return err
+}
+
+// GracefulCollectorWithMetrics is CollectorWithMetrics which swallows collection errors,
+// so periodic collections go on after failures
+type GracefulCollectorWithMetrics struct {
+	collector *CollectorWithMetrics
+}
+
+func NewGracefulCollectorWithMetrics(
+	logger *zap.Logger,
+	metrics *CollectProcessMetrics,
+	collectorName string,
+	collector Interface,
+) *GracefulCollectorWithMetrics {
+	return &GracefulCollectorWithMetrics{
+		collector: NewCollectorWithMetrics(logger, metrics, collectorName, collector),
+	}
+}
+
+func (c *GracefulCollectorWithMetrics) Collect(ctx context.Context) error {
+	_ = c.collector.Collect(ctx)
+
 	return nil
 }
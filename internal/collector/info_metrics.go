@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Info metrics export descriptive labels once per object, so they can be stripped from the value metrics
+// and joined from the info metrics by ids instead
+
+func newServiceInfoGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pagerduty_service_info",
+			Help: "Service info, one series per service team.",
+		},
+		[]string{"service_id", "name", "team_id", "escalation_policy_id", "status"},
+	)
+}
+
+func newEscalationPolicyInfoGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pagerduty_escalation_policy_info",
+			Help: "Escalation policy info.",
+		},
+		[]string{"escalation_policy_id", "name"},
+	)
+}
+
+func newPriorityInfoGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pagerduty_priority_info",
+			Help: "Incident priority info with the priority order.",
+		},
+		[]string{"priority_id", "name", "order"},
+	)
+}
+
+func newTeamInfoGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pagerduty_team_info",
+			Help: "Team info.",
+		},
+		[]string{"team_id", "name"},
+	)
+}
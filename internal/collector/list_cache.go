@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+// listCacheMaxAge is shorter than scrape intervals, so the collectors scraping in the same cycle
+// share a single listing and the next cycle lists again
+const listCacheMaxAge = 30 * time.Second
+
+// ServicesCache shares all services listed by the collectors of the same scrape cycle,
+// the returned list must not be modified
+type ServicesCache struct {
+	pdClient pagerduty.Client
+
+	mu       sync.Mutex
+	services []gopagerduty.Service
+	listedAt time.Time
+}
+
+func NewServicesCache(pdClient pagerduty.Client) *ServicesCache {
+	return &ServicesCache{pdClient: pdClient}
+}
+
+// List returns the services listed in the current cycle or lists them, concurrent callers wait for the same listing
+func (c *ServicesCache) List(ctx context.Context) ([]gopagerduty.Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.listedAt.IsZero() && time.Since(c.listedAt) < listCacheMaxAge {
+		return c.services, nil
+	}
+
+	services, err := listAllServices(ctx, c.pdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	c.services, c.listedAt = services, time.Now()
+
+	return services, nil
+}
+
+// UsersCache shares all users listed by the collectors of the same scrape cycle,
+// the returned list must not be modified
+type UsersCache struct {
+	pdClient pagerduty.Client
+
+	mu       sync.Mutex
+	users    []gopagerduty.User
+	listedAt time.Time
+}
+
+func NewUsersCache(pdClient pagerduty.Client) *UsersCache {
+	return &UsersCache{pdClient: pdClient}
+}
+
+// List returns the users listed in the current cycle or lists them, concurrent callers wait for the same listing
+func (c *UsersCache) List(ctx context.Context) ([]gopagerduty.User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.listedAt.IsZero() && time.Since(c.listedAt) < listCacheMaxAge {
+		return c.users, nil
+	}
+
+	users, err := listAllUsers(ctx, c.pdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	c.users, c.listedAt = users, time.Now()
+
+	return users, nil
+}
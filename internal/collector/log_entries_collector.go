@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+// logEntriesWindow bounds the time range listed at once, so the offset pagination of a long range,
+// e.g. after downtime, stays below the PagerDuty offset limit and the cursor is saved after each window
+const logEntriesWindow = time.Hour
+
+// logEntriesCursor is the position of the last consumed log entries.
+// Since is inclusive, so ids of the entries created at the cursor time are kept to not count them twice.
+type logEntriesCursor struct {
+	Time time.Time `json:"time"`
+	IDs  []string  `json:"ids"`
+}
+
+type LogEntriesCollector struct {
+	logger    *zap.Logger
+	pdClient  pagerduty.Client
+	statePath string
+
+	cursor *logEntriesCursor
+
+	notificationsCounter    *cardinality.CounterVec
+	escalationsCounter      *cardinality.CounterVec
+	acknowledgementsCounter *cardinality.CounterVec
+}
+
+// NewLogEntriesCollector returns collector counting log entries since the cursor persisted in statePath,
+// the cursor is kept in memory only if statePath is empty. Entries are counted since lookBack before start
+// if there is no cursor yet.
+func NewLogEntriesCollector(
+	logger *zap.Logger,
+	pdClient pagerduty.Client,
+	statePath string,
+	lookBack time.Duration,
+	limiter *cardinality.Limiter,
+	registerer prometheus.Registerer,
+) (*LogEntriesCollector, error) {
+	c := &LogEntriesCollector{
+		logger:    logger,
+		pdClient:  pdClient,
+		statePath: statePath,
+
+		notificationsCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_log_entries_notifications_total",
+				Help: "The number of notifications sent to users by channel type.",
+			},
+			[]string{"user_id", "channel_type"},
+		),
+		escalationsCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_log_entries_escalations_total",
+				Help: "The number of incident escalations by escalation policy and the escalated to level.",
+			},
+			[]string{"escalation_policy_id", "level"},
+		),
+		acknowledgementsCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_log_entries_acknowledgements_total",
+				Help: "The number of incident acknowledgements by agent.",
+			},
+			[]string{"agent_id", "agent_type"},
+		),
+	}
+
+	cursor, err := c.loadCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor == nil {
+		cursor = &logEntriesCursor{Time: time.Now().UTC().Add(-lookBack)}
+	}
+
+	c.cursor = cursor
+
+	registerer.MustRegister(c.notificationsCounter, c.escalationsCounter, c.acknowledgementsCounter)
+
+	return c, nil
+}
+
+func (c *LogEntriesCollector) Collect(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	for {
+		until := c.cursor.Time.Add(logEntriesWindow)
+
+		last := !until.Before(now)
+		if last {
+			until = now
+		}
+
+		entries, err := c.pdClient.ListAllLogEntries(ctx, c.cursor.Time, until)
+		if err != nil {
+			return err
+		}
+
+		c.consume(entries)
+
+		// the window is listed completely, so the cursor is moved to its end even without entries at the end
+		if !last && c.cursor.Time.Before(until) {
+			c.cursor = &logEntriesCursor{Time: until}
+		}
+
+		if err := c.saveCursor(); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// consume counts the entries which are not consumed yet and moves the cursor to the last of them
+func (c *LogEntriesCollector) consume(entries []pagerduty.LogEntry) {
+	consumed := make(map[string]struct{}, len(c.cursor.IDs))
+	for _, id := range c.cursor.IDs {
+		consumed[id] = struct{}{}
+	}
+
+	next := &logEntriesCursor{Time: c.cursor.Time, IDs: append([]string(nil), c.cursor.IDs...)}
+
+	for _, entry := range entries {
+		if entry.CreatedAt.Before(c.cursor.Time) {
+			continue
+		}
+
+		if _, ok := consumed[entry.ID]; ok {
+			continue
+		}
+
+		c.countEntry(entry)
+
+		switch {
+		case entry.CreatedAt.After(next.Time):
+			next = &logEntriesCursor{Time: entry.CreatedAt, IDs: []string{entry.ID}}
+		case entry.CreatedAt.Equal(next.Time):
+			next.IDs = append(next.IDs, entry.ID)
+		}
+	}
+
+	c.cursor = next
+}
+
+func (c *LogEntriesCollector) countEntry(entry pagerduty.LogEntry) {
+	switch entry.Type {
+	case pagerduty.NotifyLogEntryType:
+		c.notificationsCounter.With(prometheus.Labels{
+			"user_id":      entry.User.ID,
+			"channel_type": notificationChannelType(entry.Channel),
+		}).Inc()
+	case pagerduty.EscalateLogEntryType:
+		policyID := entry.EscalationPolicy.ID
+		if policyID == "" {
+			policyID = entry.Incident.EscalationPolicy.ID
+		}
+
+		var level string
+		if entry.Level > 0 {
+			level = strconv.Itoa(entry.Level)
+		}
+
+		c.escalationsCounter.With(prometheus.Labels{
+			"escalation_policy_id": policyID,
+			"level":                level,
+		}).Inc()
+	case pagerduty.AcknowledgeLogEntryType:
+		c.acknowledgementsCounter.With(prometheus.Labels{
+			"agent_id":   entry.Agent.ID,
+			"agent_type": strings.TrimSuffix(entry.Agent.Type, "_reference"),
+		}).Inc()
+	}
+}
+
+// notificationChannelType returns sms, phone, email, push etc. from channel type or its notification type,
+// e.g. sms_notification
+func notificationChannelType(channel pagerduty.LogEntryChannel) string {
+	channelType := channel.Type
+	if channel.Notification != nil && channel.Notification.Type != "" {
+		channelType = channel.Notification.Type
+	}
+
+	return notificationType(channelType)
+}
+
+// notificationType trims _notification suffix of notification types, e.g. sms_notification
+func notificationType(t string) string {
+	return strings.TrimSuffix(t, "_notification")
+}
+
+func (c *LogEntriesCollector) loadCursor() (*logEntriesCursor, error) {
+	if c.statePath == "" {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(c.statePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "read log entries state")
+	}
+
+	var cursor logEntriesCursor
+
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, errors.Wrap(err, "unmarshal log entries state")
+	}
+
+	c.logger.Info("Log entries cursor loaded", zap.Time("cursor", cursor.Time))
+
+	return &cursor, nil
+}
+
+// saveCursor writes the cursor to a temporary file renamed to the state file, so the state is not left partially written
+func (c *LogEntriesCollector) saveCursor() error {
+	if c.statePath == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(c.cursor)
+	if err != nil {
+		return errors.Wrap(err, "marshal log entries state")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.statePath), filepath.Base(c.statePath)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "create log entries state temp file")
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "write log entries state")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close log entries state")
+	}
+
+	if err := os.Rename(tmp.Name(), c.statePath); err != nil {
+		return errors.Wrap(err, "rename log entries state")
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+type listedWindow struct {
+	since, until time.Time
+}
+
+// fakeLogEntriesClient returns the entries created in [since, until] and fails the listing of failWindow
+type fakeLogEntriesClient struct {
+	pagerduty.Client
+
+	entries    []pagerduty.LogEntry
+	failWindow int
+	windows    []listedWindow
+}
+
+func (c *fakeLogEntriesClient) ListAllLogEntries(_ context.Context, since, until time.Time) ([]pagerduty.LogEntry, error) {
+	c.windows = append(c.windows, listedWindow{since: since, until: until})
+
+	if len(c.windows) == c.failWindow {
+		return nil, errors.New("list log entries failed")
+	}
+
+	var entries []pagerduty.LogEntry
+
+	for _, e := range c.entries {
+		if !e.CreatedAt.Before(since) && !e.CreatedAt.After(until) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func notifyLogEntry(id string, createdAt time.Time) pagerduty.LogEntry {
+	return pagerduty.LogEntry{
+		APIObject: gopagerduty.APIObject{ID: id, Type: pagerduty.NotifyLogEntryType},
+		CreatedAt: createdAt,
+		User:      gopagerduty.APIObject{ID: "U1"},
+		Channel:   pagerduty.LogEntryChannel{Type: "sms_notification"},
+	}
+}
+
+func TestLogEntriesCollectorCollect(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	cursorTime := now.Add(-10 * time.Minute)
+
+	tests := []struct {
+		name string
+
+		cursor     logEntriesCursor
+		entries    []pagerduty.LogEntry
+		failWindow int
+
+		wantErr           bool
+		wantNotifications float64
+		wantWindows       int
+		wantCursor        logEntriesCursor
+	}{
+		{
+			name:   "entries at the cursor time are not counted twice",
+			cursor: logEntriesCursor{Time: cursorTime, IDs: []string{"A"}},
+			entries: []pagerduty.LogEntry{
+				notifyLogEntry("A", cursorTime),
+				notifyLogEntry("B", cursorTime),
+				notifyLogEntry("C", cursorTime.Add(time.Minute)),
+				notifyLogEntry("D", cursorTime.Add(time.Minute)),
+			},
+			wantNotifications: 3,
+			wantWindows:       1,
+			wantCursor:        logEntriesCursor{Time: cursorTime.Add(time.Minute), IDs: []string{"C", "D"}},
+		},
+		{
+			name:        "cursor is kept without new entries",
+			cursor:      logEntriesCursor{Time: cursorTime, IDs: []string{"A"}},
+			entries:     []pagerduty.LogEntry{notifyLogEntry("A", cursorTime)},
+			wantWindows: 1,
+			wantCursor:  logEntriesCursor{Time: cursorTime, IDs: []string{"A"}},
+		},
+		{
+			name:   "long range is listed in windows",
+			cursor: logEntriesCursor{Time: now.Add(-150 * time.Minute)},
+			entries: []pagerduty.LogEntry{
+				notifyLogEntry("A", now.Add(-140*time.Minute)),
+				notifyLogEntry("B", now.Add(-90*time.Minute)),
+			},
+			wantNotifications: 2,
+			wantWindows:       3,
+			wantCursor:        logEntriesCursor{Time: now.Add(-30 * time.Minute)},
+		},
+		{
+			name:   "entry at the window end is counted once",
+			cursor: logEntriesCursor{Time: now.Add(-90 * time.Minute)},
+			entries: []pagerduty.LogEntry{
+				notifyLogEntry("A", now.Add(-30*time.Minute)),
+			},
+			wantNotifications: 1,
+			wantWindows:       2,
+			wantCursor:        logEntriesCursor{Time: now.Add(-30 * time.Minute), IDs: []string{"A"}},
+		},
+		{
+			name:   "cursor is saved after each listed window",
+			cursor: logEntriesCursor{Time: now.Add(-150 * time.Minute)},
+			entries: []pagerduty.LogEntry{
+				notifyLogEntry("A", now.Add(-140*time.Minute)),
+				notifyLogEntry("B", now.Add(-60*time.Minute)),
+			},
+			failWindow:        2,
+			wantErr:           true,
+			wantNotifications: 1,
+			wantWindows:       2,
+			wantCursor:        logEntriesCursor{Time: now.Add(-90 * time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "state.json")
+
+			b, err := json.Marshal(tt.cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ioutil.WriteFile(statePath, b, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			client := &fakeLogEntriesClient{entries: tt.entries, failWindow: tt.failWindow}
+
+			limiter, err := cardinality.NewLimiter(zap.NewNop(), 0, cardinality.ActionOverflow, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := NewLogEntriesCollector(zap.NewNop(), client, statePath, 0, limiter, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.Collect(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			notifications := testutil.ToFloat64(c.notificationsCounter.With(prometheus.Labels{"user_id": "U1", "channel_type": "sms"}))
+			if notifications != tt.wantNotifications {
+				t.Errorf("notifications = %v, want %v", notifications, tt.wantNotifications)
+			}
+
+			if len(client.windows) != tt.wantWindows {
+				t.Fatalf("listed %d windows, want %d", len(client.windows), tt.wantWindows)
+			}
+
+			for i, w := range client.windows {
+				if w.until.Sub(w.since) > logEntriesWindow {
+					t.Errorf("window %d is %s long, want at most %s", i, w.until.Sub(w.since), logEntriesWindow)
+				}
+
+				if i > 0 && w.since.After(client.windows[i-1].until) {
+					t.Errorf("window %d starts at %s after the previous window end %s", i, w.since, client.windows[i-1].until)
+				}
+			}
+
+			b, err = ioutil.ReadFile(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var saved logEntriesCursor
+			if err := json.Unmarshal(b, &saved); err != nil {
+				t.Fatal(err)
+			}
+
+			if !saved.Time.Equal(tt.wantCursor.Time) || !equalStrings(saved.IDs, tt.wantCursor.IDs) {
+				t.Errorf("saved cursor = %+v, want %+v", saved, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestLogEntriesCollectorEscalationLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		entry      pagerduty.LogEntry
+		wantLabels prometheus.Labels
+	}{
+		{
+			name: "escalation policy and level of the entry",
+			entry: pagerduty.LogEntry{
+				EscalationPolicy: gopagerduty.APIObject{ID: "EP1"},
+				Level:            2,
+				Incident:         pagerduty.LogEntryIncident{EscalationPolicy: gopagerduty.APIObject{ID: "EP2"}},
+			},
+			wantLabels: prometheus.Labels{"escalation_policy_id": "EP1", "level": "2"},
+		},
+		{
+			name: "incident escalation policy without entry escalation policy",
+			entry: pagerduty.LogEntry{
+				Incident: pagerduty.LogEntryIncident{EscalationPolicy: gopagerduty.APIObject{ID: "EP2"}},
+			},
+			wantLabels: prometheus.Labels{"escalation_policy_id": "EP2", "level": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := cardinality.NewLimiter(zap.NewNop(), 0, cardinality.ActionOverflow, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := NewLogEntriesCollector(zap.NewNop(), &fakeLogEntriesClient{}, "", 0, limiter, prometheus.NewRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.entry.Type = pagerduty.EscalateLogEntryType
+			c.countEntry(tt.entry)
+
+			if got := testutil.ToFloat64(c.escalationsCounter.With(tt.wantLabels)); got != 1 {
+				t.Errorf("escalations%v = %v, want 1", tt.wantLabels, got)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,188 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const maintenanceWindowsRequestLimit = 100
+
+const (
+	maintenanceWindowStateActive   = "active"
+	maintenanceWindowStateUpcoming = "upcoming"
+)
+
+// maintenanceWindowFilters maps maintenance window states to /maintenance_windows filters
+var maintenanceWindowFilters = map[string]string{
+	maintenanceWindowStateActive:   "ongoing",
+	maintenanceWindowStateUpcoming: "future",
+}
+
+type MaintenanceWindowsCollector struct {
+	logger   *zap.Logger
+	pdClient pagerduty.Client
+	services *ServicesCache
+
+	windowStartGauge          *prometheus.GaugeVec
+	windowEndGauge            *prometheus.GaugeVec
+	serviceInMaintenanceGauge *prometheus.GaugeVec
+}
+
+func NewMaintenanceWindowsCollector(
+	logger *zap.Logger,
+	pdClient pagerduty.Client,
+	services *ServicesCache,
+	registerer prometheus.Registerer,
+) *MaintenanceWindowsCollector {
+	windowLabels := []string{"maintenance_window_id", "service_id", "state"}
+
+	c := &MaintenanceWindowsCollector{
+		logger:   logger,
+		pdClient: pdClient,
+		services: services,
+
+		windowStartGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_maintenance_window_start_timestamp_seconds",
+				Help: "Start timestamp of the active or upcoming maintenance window of the service.",
+			},
+			windowLabels,
+		),
+		windowEndGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_maintenance_window_end_timestamp_seconds",
+				Help: "End timestamp of the active or upcoming maintenance window of the service.",
+			},
+			windowLabels,
+		),
+		serviceInMaintenanceGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_in_maintenance",
+				Help: "1 if the service is in an active maintenance window, 0 otherwise.",
+			},
+			[]string{"service_id"},
+		),
+	}
+
+	registerer.MustRegister(c.windowStartGauge, c.windowEndGauge, c.serviceInMaintenanceGauge)
+
+	return c
+}
+
+func (c *MaintenanceWindowsCollector) Collect(ctx context.Context) error {
+	windows := make(map[string][]gopagerduty.MaintenanceWindow, len(maintenanceWindowFilters))
+
+	for state, filter := range maintenanceWindowFilters {
+		list, err := c.listMaintenanceWindows(ctx, filter)
+		if err != nil {
+			return errors.Wrapf(err, "list %s maintenance windows", state)
+		}
+
+		windows[state] = list
+	}
+
+	services, err := c.services.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.windowStartGauge.Reset()
+	c.windowEndGauge.Reset()
+	c.serviceInMaintenanceGauge.Reset()
+
+	inMaintenance := make(map[string]bool)
+
+	for state, list := range windows {
+		for _, window := range list {
+			c.setWindowMetrics(state, window)
+
+			if state != maintenanceWindowStateActive {
+				continue
+			}
+
+			for _, service := range window.Services {
+				inMaintenance[service.ID] = true
+			}
+		}
+	}
+
+	for _, service := range services {
+		var v float64
+		if inMaintenance[service.ID] {
+			v = 1
+		}
+
+		c.serviceInMaintenanceGauge.With(prometheus.Labels{"service_id": service.ID}).Set(v)
+	}
+
+	return nil
+}
+
+func (c *MaintenanceWindowsCollector) setWindowMetrics(state string, window gopagerduty.MaintenanceWindow) {
+	startAt, err := time.Parse(time.RFC3339, window.StartTime)
+	if err != nil {
+		c.logger.Error(
+			"Parse maintenance window start time error, skipping...",
+			zap.String("maintenance_window_id", window.ID),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	endAt, err := time.Parse(time.RFC3339, window.EndTime)
+	if err != nil {
+		c.logger.Error(
+			"Parse maintenance window end time error, skipping...",
+			zap.String("maintenance_window_id", window.ID),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	for _, service := range window.Services {
+		labels := prometheus.Labels{
+			"maintenance_window_id": window.ID,
+			"service_id":            service.ID,
+			"state":                 state,
+		}
+
+		c.windowStartGauge.With(labels).Set(float64(startAt.Unix()))
+		c.windowEndGauge.With(labels).Set(float64(endAt.Unix()))
+	}
+}
+
+func (c *MaintenanceWindowsCollector) listMaintenanceWindows(
+	ctx context.Context,
+	filter string,
+) ([]gopagerduty.MaintenanceWindow, error) {
+	var windows []gopagerduty.MaintenanceWindow
+
+	listOpts := gopagerduty.ListMaintenanceWindowsOptions{Filter: filter}
+	listOpts.Limit = maintenanceWindowsRequestLimit
+
+	for {
+		list, err := c.pdClient.ListMaintenanceWindowsWithContext(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, list.MaintenanceWindows...)
+
+		listOpts.Offset += list.Limit
+		if !list.More {
+			break
+		}
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,239 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const notificationsRequestLimit = 100
+
+type userNotificationsKey struct {
+	userID           string
+	notificationType string
+	hour             int
+}
+
+type NotificationsCollector struct {
+	logger         *zap.Logger
+	pdClient       pagerduty.Client
+	users          *UsersCache
+	window         time.Duration
+	nightStartHour int
+	nightEndHour   int
+
+	userNotificationsGauge   *cardinality.GaugeVec
+	userPagingLoadGauge      *cardinality.GaugeVec
+	userNightPagingLoadGauge *cardinality.GaugeVec
+}
+
+// NewNotificationsCollector returns collector of the notifications sent in the sliding window before the scrape.
+// Night hours are [nightStartHour, nightEndHour) in the user time zone and wrap around midnight if start is after end.
+func NewNotificationsCollector(
+	logger *zap.Logger,
+	pdClient pagerduty.Client,
+	users *UsersCache,
+	window time.Duration,
+	nightStartHour, nightEndHour int,
+	limiter *cardinality.Limiter,
+	registerer prometheus.Registerer,
+) (*NotificationsCollector, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("notifications window must be positive, got %s", window)
+	}
+
+	for _, h := range []int{nightStartHour, nightEndHour} {
+		if h < 0 || h > 23 {
+			return nil, fmt.Errorf("night hour must be in [0, 23], got %d", h)
+		}
+	}
+
+	c := &NotificationsCollector{
+		logger:         logger,
+		pdClient:       pdClient,
+		users:          users,
+		window:         window,
+		nightStartHour: nightStartHour,
+		nightEndHour:   nightEndHour,
+
+		userNotificationsGauge: limiter.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_user_notifications",
+				Help: "The number of notifications sent to the user in the window by type and hour of day in the user time zone.",
+			},
+			[]string{"user_id", "type", "hour"},
+		),
+		userPagingLoadGauge: limiter.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_user_paging_load",
+				Help: "The number of notifications sent to the user in the window.",
+			},
+			[]string{"user_id"},
+		),
+		userNightPagingLoadGauge: limiter.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_user_night_paging_load",
+				Help: "The number of notifications sent to the user in the window at night in the user time zone.",
+			},
+			[]string{"user_id"},
+		),
+	}
+
+	registerer.MustRegister(c.userNotificationsGauge, c.userPagingLoadGauge, c.userNightPagingLoadGauge)
+
+	return c, nil
+}
+
+func (c *NotificationsCollector) Collect(ctx context.Context) error {
+	locations, err := c.listUserLocations(ctx)
+	if err != nil {
+		return errors.Wrap(err, "list users time zones")
+	}
+
+	until := time.Now().UTC()
+
+	notifications, err := c.listNotifications(ctx, until.Add(-c.window), until)
+	if err != nil {
+		return errors.Wrap(err, "list notifications")
+	}
+
+	userNotifications := make(map[userNotificationsKey]int)
+	pagingLoad := make(map[string]int, len(locations))
+	nightPagingLoad := make(map[string]int, len(locations))
+
+	for userID := range locations {
+		pagingLoad[userID] = 0
+		nightPagingLoad[userID] = 0
+	}
+
+	for _, notification := range notifications {
+		startedAt, err := time.Parse(time.RFC3339, notification.StartedAt)
+		if err != nil {
+			c.logger.Error(
+				"Parse notification start time error, skipping...",
+				zap.String("notification_id", notification.ID),
+				zap.Error(err),
+			)
+
+			continue
+		}
+
+		userID := notification.User.ID
+
+		loc, ok := locations[userID]
+		if !ok {
+			loc = time.UTC
+		}
+
+		hour := startedAt.In(loc).Hour()
+
+		userNotifications[userNotificationsKey{
+			userID:           userID,
+			notificationType: notificationType(notification.Type),
+			hour:             hour,
+		}]++
+
+		pagingLoad[userID]++
+
+		if c.isNightHour(hour) {
+			nightPagingLoad[userID]++
+		}
+	}
+
+	c.userNotificationsGauge.Reset()
+	c.userPagingLoadGauge.Reset()
+	c.userNightPagingLoadGauge.Reset()
+
+	for key, count := range userNotifications {
+		c.userNotificationsGauge.With(prometheus.Labels{
+			"user_id": key.userID,
+			"type":    key.notificationType,
+			"hour":    strconv.Itoa(key.hour),
+		}).Set(float64(count))
+	}
+
+	for userID, count := range pagingLoad {
+		c.userPagingLoadGauge.With(prometheus.Labels{"user_id": userID}).Set(float64(count))
+	}
+
+	for userID, count := range nightPagingLoad {
+		c.userNightPagingLoadGauge.With(prometheus.Labels{"user_id": userID}).Set(float64(count))
+	}
+
+	return nil
+}
+
+func (c *NotificationsCollector) isNightHour(hour int) bool {
+	if c.nightStartHour <= c.nightEndHour {
+		return hour >= c.nightStartHour && hour < c.nightEndHour
+	}
+
+	return hour >= c.nightStartHour || hour < c.nightEndHour
+}
+
+// listUserLocations lists users time zones, UTC is used for users with unknown time zone
+func (c *NotificationsCollector) listUserLocations(ctx context.Context) (map[string]*time.Location, error) {
+	users, err := c.users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]*time.Location, len(users))
+
+	for _, user := range users {
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			c.logger.Warn(
+				"Load user time zone error, using UTC...",
+				zap.String("user_id", user.ID),
+				zap.String("time_zone", user.Timezone),
+				zap.Error(err),
+			)
+
+			loc = time.UTC
+		}
+
+		locations[user.ID] = loc
+	}
+
+	return locations, nil
+}
+
+func (c *NotificationsCollector) listNotifications(
+	ctx context.Context,
+	since, until time.Time,
+) ([]gopagerduty.Notification, error) {
+	var notifications []gopagerduty.Notification
+
+	listOpts := gopagerduty.ListNotificationOptions{
+		Since: since.Format(time.RFC3339),
+		Until: until.Format(time.RFC3339),
+	}
+	listOpts.Limit = notificationsRequestLimit
+
+	for {
+		list, err := c.pdClient.ListNotificationsWithContext(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, list.Notifications...)
+
+		listOpts.Offset += list.Limit
+		if !list.More {
+			break
+		}
+	}
+
+	return notifications, nil
+}
@@ -47,3 +47,18 @@ func (c *PeriodicCollector) Collect(ctx context.Context) error {
 
 	return eg.Wait()
 }
+
+// CollectOnce runs every collector a single time, used for one-shot collections.
+func (c *PeriodicCollector) CollectOnce(ctx context.Context) error {
+	eg, gCtx := errgroup.WithContext(ctx)
+
+	for i := range c.collectors {
+		collector := c.collectors[i]
+
+		eg.Go(func() error {
+			return collector.Collect(gCtx)
+		})
+	}
+
+	return eg.Wait()
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+// PriorityNames is in-memory priority id to name cache filled by PrioritiesCollector
+type PriorityNames struct {
+	mu    sync.RWMutex
+	names map[string]string
+}
+
+func NewPriorityNames() *PriorityNames {
+	return &PriorityNames{names: make(map[string]string)}
+}
+
+// PriorityName returns the priority name, empty if the priority is unknown
+func (p *PriorityNames) PriorityName(id string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.names[id]
+}
+
+func (p *PriorityNames) set(names map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.names = names
+}
+
+type PrioritiesCollector struct {
+	pdClient      pagerduty.Client
+	priorityNames *PriorityNames
+
+	priorityInfoGauge *prometheus.GaugeVec
+}
+
+func NewPrioritiesCollector(
+	pdClient pagerduty.Client,
+	priorityNames *PriorityNames,
+	registerer prometheus.Registerer,
+) *PrioritiesCollector {
+	c := &PrioritiesCollector{
+		pdClient:      pdClient,
+		priorityNames: priorityNames,
+
+		priorityInfoGauge: newPriorityInfoGauge(),
+	}
+
+	registerer.MustRegister(c.priorityInfoGauge)
+
+	return c
+}
+
+func (c *PrioritiesCollector) Collect(ctx context.Context) error {
+	priorities, err := c.pdClient.ListAllPriorities(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string, len(priorities))
+
+	c.priorityInfoGauge.Reset()
+
+	for _, priority := range priorities {
+		names[priority.ID] = priority.Name
+
+		c.priorityInfoGauge.With(prometheus.Labels{
+			"priority_id": priority.ID,
+			"name":        priority.Name,
+			"order":       strconv.Itoa(priority.Order),
+		}).Set(1)
+	}
+
+	c.priorityNames.set(names)
+
+	return nil
+}
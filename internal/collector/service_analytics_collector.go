@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"github.com/24el/pagerduty-prometheus-exporter/internal/labelpolicy"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
 )
 
@@ -25,6 +26,7 @@ func (m *ServiceAnalyticMetrics) prepareMetricName(metricName string) string {
 
 func RegisterServiceAnalyticMetricsFromNames(
 	registerer prometheus.Registerer,
+	labelPolicy *labelpolicy.Policy,
 	metricNames []pagerduty.ReportMetricName,
 ) ServiceAnalyticMetrics {
 	gaugeMetrics := make(ServiceAnalyticMetrics, len(metricNames))
@@ -34,7 +36,7 @@ func RegisterServiceAnalyticMetricsFromNames(
 			prometheus.GaugeOpts{
 				Name: gaugeMetrics.prepareMetricName(string(mn)),
 			},
-			[]string{"service_id", "service_name", "report_interval"},
+			labelPolicy.LabelNames([]string{"service_id", "service_name", "report_interval"}),
 		)
 
 		registerer.MustRegister(gaugeMetrics[mn])
@@ -46,6 +48,7 @@ func RegisterServiceAnalyticMetricsFromNames(
 type ServiceAnalyticsCollector struct {
 	logger      *zap.Logger
 	client      pagerduty.Client
+	labelPolicy *labelpolicy.Policy
 	metricNames []pagerduty.ReportMetricName
 	interval    time.Duration
 
@@ -55,6 +58,7 @@ type ServiceAnalyticsCollector struct {
 func NewServiceAnalyticsCollector(
 	logger *zap.Logger,
 	client pagerduty.Client,
+	labelPolicy *labelpolicy.Policy,
 	serviceAnalyticMetrics ServiceAnalyticMetrics,
 	metricNames []pagerduty.ReportMetricName,
 	interval time.Duration,
@@ -63,6 +67,7 @@ func NewServiceAnalyticsCollector(
 		logger:      logger,
 		metricNames: metricNames,
 		client:      client,
+		labelPolicy: labelPolicy,
 		interval:    interval,
 		metrics:     serviceAnalyticMetrics,
 	}
@@ -83,11 +88,11 @@ func (c *ServiceAnalyticsCollector) Collect(ctx context.Context) error {
 	}
 
 	for _, srvMetrics := range report.Data {
-		labels := prometheus.Labels{
+		labels := c.labelPolicy.Apply(prometheus.Labels{
 			"service_id":      srvMetrics.ServiceID,
 			"service_name":    srvMetrics.ServiceName,
 			"report_interval": c.interval.String(),
-		}
+		})
 
 		for _, metricName := range c.metricNames {
 			metricVal, err := srvMetrics.GetMetricByName(metricName)
@@ -0,0 +1,212 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	gopagerduty "github.com/PagerDuty/go-pagerduty"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const servicesRequestLimit = 100
+
+var serviceStatuses = []string{"active", "warning", "critical", "maintenance", "disabled"}
+
+type ServicesCollector struct {
+	logger   *zap.Logger
+	pdClient pagerduty.Client
+	services *ServicesCache
+
+	serviceInfoGauge               *prometheus.GaugeVec
+	serviceStatusGauge             *prometheus.GaugeVec
+	serviceAckTimeoutGauge         *prometheus.GaugeVec
+	serviceAutoResolveTimeoutGauge *prometheus.GaugeVec
+	serviceAlertCreationGauge      *prometheus.GaugeVec
+	serviceIntegrationsGauge       *prometheus.GaugeVec
+	serviceLastIncidentGauge       *prometheus.GaugeVec
+}
+
+func NewServicesCollector(
+	logger *zap.Logger,
+	pdClient pagerduty.Client,
+	services *ServicesCache,
+	registerer prometheus.Registerer,
+) *ServicesCollector {
+	c := &ServicesCollector{
+		logger:   logger,
+		pdClient: pdClient,
+		services: services,
+
+		serviceInfoGauge: newServiceInfoGauge(),
+		serviceStatusGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_status",
+				Help: "Service status, 1 for the current status of the service and 0 for the others.",
+			},
+			[]string{"service_id", "status"},
+		),
+		serviceAckTimeoutGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_acknowledgement_timeout_seconds",
+				Help: "Time an acknowledged incident of the service is re-triggered after, absent if disabled.",
+			},
+			[]string{"service_id"},
+		),
+		serviceAutoResolveTimeoutGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_auto_resolve_timeout_seconds",
+				Help: "Time an incident of the service is resolved automatically after, absent if disabled.",
+			},
+			[]string{"service_id"},
+		),
+		serviceAlertCreationGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_alert_creation",
+				Help: "Service alert creation mode.",
+			},
+			[]string{"service_id", "alert_creation"},
+		),
+		serviceIntegrationsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_integrations",
+				Help: "The number of service integrations.",
+			},
+			[]string{"service_id"},
+		),
+		serviceLastIncidentGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_service_last_incident_timestamp_seconds",
+				Help: "Timestamp of the last incident of the service, absent if the service has no incidents.",
+			},
+			[]string{"service_id"},
+		),
+	}
+
+	registerer.MustRegister(
+		c.serviceInfoGauge,
+		c.serviceStatusGauge,
+		c.serviceAckTimeoutGauge,
+		c.serviceAutoResolveTimeoutGauge,
+		c.serviceAlertCreationGauge,
+		c.serviceIntegrationsGauge,
+		c.serviceLastIncidentGauge,
+	)
+
+	return c
+}
+
+func (c *ServicesCollector) Collect(ctx context.Context) error {
+	services, err := c.services.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.serviceInfoGauge.Reset()
+	c.serviceStatusGauge.Reset()
+	c.serviceAckTimeoutGauge.Reset()
+	c.serviceAutoResolveTimeoutGauge.Reset()
+	c.serviceAlertCreationGauge.Reset()
+	c.serviceIntegrationsGauge.Reset()
+	c.serviceLastIncidentGauge.Reset()
+
+	for _, service := range services {
+		c.setServiceMetrics(service)
+
+		teamIDs := []string{""}
+		if len(service.Teams) > 0 {
+			teamIDs = teamIDs[:0]
+			for _, team := range service.Teams {
+				teamIDs = append(teamIDs, team.ID)
+			}
+		}
+
+		for _, teamID := range teamIDs {
+			c.serviceInfoGauge.With(prometheus.Labels{
+				"service_id":           service.ID,
+				"name":                 service.Name,
+				"team_id":              teamID,
+				"escalation_policy_id": service.EscalationPolicy.ID,
+				"status":               service.Status,
+			}).Set(1)
+		}
+	}
+
+	return nil
+}
+
+func (c *ServicesCollector) setServiceMetrics(service gopagerduty.Service) {
+	serviceLabels := prometheus.Labels{"service_id": service.ID}
+
+	for _, status := range serviceStatuses {
+		var v float64
+		if status == service.Status {
+			v = 1
+		}
+
+		c.serviceStatusGauge.With(prometheus.Labels{
+			"service_id": service.ID,
+			"status":     status,
+		}).Set(v)
+	}
+
+	if service.AcknowledgementTimeout != nil {
+		c.serviceAckTimeoutGauge.With(serviceLabels).Set(float64(*service.AcknowledgementTimeout))
+	}
+
+	if service.AutoResolveTimeout != nil {
+		c.serviceAutoResolveTimeoutGauge.With(serviceLabels).Set(float64(*service.AutoResolveTimeout))
+	}
+
+	if service.AlertCreation != "" {
+		c.serviceAlertCreationGauge.With(prometheus.Labels{
+			"service_id":     service.ID,
+			"alert_creation": service.AlertCreation,
+		}).Set(1)
+	}
+
+	c.serviceIntegrationsGauge.With(serviceLabels).Set(float64(len(service.Integrations)))
+
+	if service.LastIncidentTimestamp == "" {
+		return
+	}
+
+	lastIncidentAt, err := time.Parse(time.RFC3339, service.LastIncidentTimestamp)
+	if err != nil {
+		c.logger.Error(
+			"Parse service last incident timestamp error, skipping...",
+			zap.String("service_id", service.ID),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	c.serviceLastIncidentGauge.With(serviceLabels).Set(float64(lastIncidentAt.Unix()))
+}
+
+func listAllServices(ctx context.Context, pdClient pagerduty.Client) ([]gopagerduty.Service, error) {
+	var services []gopagerduty.Service
+
+	listOpts := gopagerduty.ListServiceOptions{}
+	listOpts.Limit = servicesRequestLimit
+
+	for {
+		list, err := pdClient.ListServicesWithContext(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, list.Services...)
+
+		listOpts.Offset += list.Limit
+		if !list.More {
+			break
+		}
+	}
+
+	return services, nil
+}
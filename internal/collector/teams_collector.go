@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+type TeamsCollector struct {
+	pdClient pagerduty.Client
+
+	teamInfoGauge   *prometheus.GaugeVec
+	teamMemberGauge *prometheus.GaugeVec
+}
+
+func NewTeamsCollector(pdClient pagerduty.Client, registerer prometheus.Registerer) *TeamsCollector {
+	c := &TeamsCollector{
+		pdClient: pdClient,
+
+		teamInfoGauge: newTeamInfoGauge(),
+		teamMemberGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "pagerduty_team_member",
+				Help: "Team member with the role in the team.",
+			},
+			[]string{"team_id", "user_id", "role"},
+		),
+	}
+
+	registerer.MustRegister(c.teamInfoGauge, c.teamMemberGauge)
+
+	return c
+}
+
+func (c *TeamsCollector) Collect(ctx context.Context) error {
+	teams, err := c.pdClient.ListAllTeams(ctx)
+	if err != nil {
+		return err
+	}
+
+	teamMembers := make(map[string][]pagerduty.TeamMember, len(teams))
+
+	for _, team := range teams {
+		members, err := c.pdClient.ListAllTeamMembers(ctx, team.ID)
+		if err != nil {
+			return errors.Wrapf(err, "list team %s members", team.ID)
+		}
+
+		teamMembers[team.ID] = members
+	}
+
+	c.teamInfoGauge.Reset()
+	c.teamMemberGauge.Reset()
+
+	for _, team := range teams {
+		c.teamInfoGauge.With(prometheus.Labels{
+			"team_id": team.ID,
+			"name":    team.Name,
+		}).Set(1)
+
+		for _, member := range teamMembers[team.ID] {
+			c.teamMemberGauge.With(prometheus.Labels{
+				"team_id": team.ID,
+				"user_id": member.User.ID,
+				"role":    member.Role,
+			}).Set(1)
+		}
+	}
+
+	return nil
+}
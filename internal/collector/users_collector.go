@@ -7,26 +7,35 @@ import (
 
 	gopagerduty "github.com/PagerDuty/go-pagerduty"
 
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/labelpolicy"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
 )
 
 const usersRequestLimit = 100
 
 type UsersCollector struct {
-	pdClient pagerduty.Client
+	users       *UsersCache
+	labelPolicy *labelpolicy.Policy
 
-	usersGauge *prometheus.GaugeVec
+	usersGauge *cardinality.GaugeVec
 }
 
-func NewUsersCollector(pdClient pagerduty.Client, registerer prometheus.Registerer) *UsersCollector {
+func NewUsersCollector(
+	users *UsersCache,
+	labelPolicy *labelpolicy.Policy,
+	limiter *cardinality.Limiter,
+	registerer prometheus.Registerer,
+) *UsersCollector {
 	c := &UsersCollector{
-		pdClient: pdClient,
+		users:       users,
+		labelPolicy: labelPolicy,
 
-		usersGauge: prometheus.NewGaugeVec(
+		usersGauge: limiter.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "pagerduty_user",
 			},
-			[]string{
+			labelPolicy.LabelNames([]string{
 				"id",
 				"name",
 				"mail",
@@ -34,7 +43,7 @@ func NewUsersCollector(pdClient pagerduty.Client, registerer prometheus.Register
 				"color",
 				"job_title",
 				"role",
-			},
+			}),
 		),
 	}
 
@@ -44,26 +53,39 @@ func NewUsersCollector(pdClient pagerduty.Client, registerer prometheus.Register
 }
 
 func (c *UsersCollector) Collect(ctx context.Context) error {
+	users, err := c.users.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		c.usersGauge.With(c.labelPolicy.Apply(prometheus.Labels{
+			"id":        user.ID,
+			"name":      user.Name,
+			"mail":      user.Email,
+			"avatar":    user.AvatarURL,
+			"color":     user.Color,
+			"job_title": user.JobTitle,
+			"role":      user.Role,
+		})).Set(1)
+	}
+
+	return nil
+}
+
+func listAllUsers(ctx context.Context, pdClient pagerduty.Client) ([]gopagerduty.User, error) {
+	var users []gopagerduty.User
+
 	listOpts := gopagerduty.ListUsersOptions{}
 	listOpts.Limit = usersRequestLimit
 
 	for {
-		list, err := c.pdClient.ListUsersWithContext(ctx, listOpts)
+		list, err := pdClient.ListUsersWithContext(ctx, listOpts)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		for _, user := range list.Users {
-			c.usersGauge.With(prometheus.Labels{
-				"id":        user.ID,
-				"name":      user.Name,
-				"mail":      user.Email,
-				"avatar":    user.AvatarURL,
-				"color":     user.Color,
-				"job_title": user.JobTitle,
-				"role":      user.Role,
-			}).Set(1)
-		}
+		users = append(users, list.Users...)
 
 		listOpts.Offset += list.Limit
 		if !list.More {
@@ -71,5 +93,5 @@ func (c *UsersCollector) Collect(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	return users, nil
 }
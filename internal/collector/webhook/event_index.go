@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+type eventIndexItem struct {
+	value     string
+	updatedAt time.Time
+}
+
+// eventIndex correlates webhook events of the same resource, items older than ttl are evicted
+type eventIndex struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	items     map[string]eventIndexItem
+	lastPurge time.Time
+}
+
+func newEventIndex(ttl time.Duration) *eventIndex {
+	return &eventIndex{
+		ttl:   ttl,
+		items: make(map[string]eventIndexItem),
+	}
+}
+
+func (i *eventIndex) Set(key, value string, t time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.items[key] = eventIndexItem{value: value, updatedAt: t}
+
+	if time.Since(i.lastPurge) < i.ttl {
+		return
+	}
+
+	for k, item := range i.items {
+		if time.Since(item.updatedAt) > i.ttl {
+			delete(i.items, k)
+		}
+	}
+
+	i.lastPurge = time.Now()
+}
+
+func (i *eventIndex) Get(key string) (eventIndexItem, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	item, ok := i.items[key]
+
+	return item, ok
+}
+
+func (i *eventIndex) Delete(key string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.items, key)
+}
@@ -1,32 +1,73 @@
 package webhook
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/24el/pagerduty-prometheus-exporter/internal/cardinality"
+	"github.com/24el/pagerduty-prometheus-exporter/internal/labelpolicy"
 	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
 )
 
+const (
+	incidentServicesTTL   = 7 * 24 * time.Hour
+	pendingRespondersTTL  = 24 * time.Hour
+	unknownServiceIDLabel = ""
+)
+
+// PriorityNameResolver resolves priority names by ids, empty name is returned for unknown priorities
+type PriorityNameResolver interface {
+	PriorityName(id string) string
+}
+
 type IncidentMetricsListener struct {
-	dtFormat   string
-	registerer prometheus.Registerer
+	dtFormat      string
+	labelPolicy   *labelpolicy.Policy
+	priorityNames PriorityNameResolver
+	registerer    prometheus.Registerer
 
-	incidentEventGauge     *prometheus.GaugeVec
-	incidentEventAssignees *prometheus.GaugeVec
-	incidentEventTeams     *prometheus.GaugeVec
+	incidentServices  *eventIndex
+	pendingResponders *eventIndex
+
+	incidentEventGauge     *cardinality.GaugeVec
+	incidentEventAssignees *cardinality.GaugeVec
+	incidentEventTeams     *cardinality.GaugeVec
+
+	incidentNotesCounter             *cardinality.CounterVec
+	incidentResponderRequestsCounter *cardinality.CounterVec
+	incidentResponderRepliesCounter  *cardinality.CounterVec
+	incidentResponderReplyLatency    *prometheus.HistogramVec
+	incidentStatusUpdatesCounter     *cardinality.CounterVec
+	incidentConferenceBridgeCounter  *cardinality.CounterVec
+	incidentPriorityUpdatesCounter   *cardinality.CounterVec
+	serviceEventsCounter             *cardinality.CounterVec
 }
 
-func NewIncidentMetricsListener(dtFormat string, registerer prometheus.Registerer) *IncidentMetricsListener {
+// NewIncidentMetricsListener returns listener, priority_name label is added to the incident metrics with priority_id
+// if priorityNames is not nil
+func NewIncidentMetricsListener(
+	dtFormat string,
+	labelPolicy *labelpolicy.Policy,
+	priorityNames PriorityNameResolver,
+	limiter *cardinality.Limiter,
+	registerer prometheus.Registerer,
+) *IncidentMetricsListener {
 	l := &IncidentMetricsListener{
-		dtFormat:   dtFormat,
-		registerer: registerer,
-		incidentEventGauge: prometheus.NewGaugeVec(
+		dtFormat:      dtFormat,
+		labelPolicy:   labelPolicy,
+		priorityNames: priorityNames,
+		registerer:    registerer,
+
+		incidentServices:  newEventIndex(incidentServicesTTL),
+		pendingResponders: newEventIndex(pendingRespondersTTL),
+
+		incidentEventGauge: limiter.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "pagerduty_incident_event",
 			},
-			[]string{
+			labelPolicy.LabelNames(withPriorityNameLabel(priorityNames, []string{
 				"incident_id",
 				"type",
 				"status",
@@ -38,23 +79,92 @@ func NewIncidentMetricsListener(dtFormat string, registerer prometheus.Registere
 				"urgency",
 				"priority_id",
 				"dt",
-			},
+			})),
 		),
-		incidentEventAssignees: prometheus.NewGaugeVec(
+		incidentEventAssignees: limiter.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "pagerduty_incident_event_assignees",
 			},
-			[]string{"incident_id", "event_type", "assignee_id", "assignee_summary", "dt"},
+			labelPolicy.LabelNames([]string{"incident_id", "event_type", "assignee_id", "assignee_summary", "dt"}),
 		),
-		incidentEventTeams: prometheus.NewGaugeVec(
+		incidentEventTeams: limiter.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "pagerduty_incident_event_teams",
 			},
-			[]string{"incident_id", "event_type", "team_id", "team_summary", "dt"},
+			labelPolicy.LabelNames([]string{"incident_id", "event_type", "team_id", "team_summary", "dt"}),
+		),
+		incidentNotesCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_notes_total",
+				Help: "The number of notes added to incidents.",
+			},
+			labelPolicy.LabelNames([]string{"incident_id", "service_id"}),
+		),
+		incidentResponderRequestsCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_responder_requests_total",
+				Help: "The number of responders requested for incidents.",
+			},
+			labelPolicy.LabelNames([]string{"incident_id", "service_id", "user_id"}),
+		),
+		incidentResponderRepliesCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_responder_replies_total",
+				Help: "The number of responder request replies by state.",
+			},
+			labelPolicy.LabelNames([]string{"incident_id", "service_id", "user_id", "state"}),
+		),
+		incidentResponderReplyLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "pagerduty_incident_responder_reply_latency_seconds",
+				Help:    "The latency between responder request and its reply.",
+				Buckets: []float64{30, 60, 120, 300, 600, 900, 1800, 3600, 7200},
+			},
+			labelPolicy.LabelNames([]string{"service_id", "state"}),
+		),
+		incidentStatusUpdatesCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_status_updates_total",
+				Help: "The number of status updates published for incidents.",
+			},
+			labelPolicy.LabelNames([]string{"incident_id", "service_id"}),
+		),
+		incidentConferenceBridgeCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_conference_bridge_updates_total",
+				Help: "The number of incident conference bridge updates.",
+			},
+			labelPolicy.LabelNames([]string{"incident_id", "service_id"}),
+		),
+		incidentPriorityUpdatesCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_incident_priority_updates_total",
+				Help: "The number of incident priority updates by the new priority.",
+			},
+			labelPolicy.LabelNames(withPriorityNameLabel(priorityNames, []string{"incident_id", "service_id", "priority_id"})),
+		),
+		serviceEventsCounter: limiter.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "pagerduty_service_events_total",
+				Help: "The number of service created, updated and deleted events.",
+			},
+			labelPolicy.LabelNames([]string{"service_id", "event_type"}),
 		),
 	}
 
-	l.registerer.MustRegister(l.incidentEventGauge, l.incidentEventAssignees, l.incidentEventTeams)
+	l.registerer.MustRegister(
+		l.incidentEventGauge,
+		l.incidentEventAssignees,
+		l.incidentEventTeams,
+		l.incidentNotesCounter,
+		l.incidentResponderRequestsCounter,
+		l.incidentResponderRepliesCounter,
+		l.incidentResponderReplyLatency,
+		l.incidentStatusUpdatesCounter,
+		l.incidentConferenceBridgeCounter,
+		l.incidentPriorityUpdatesCounter,
+		l.serviceEventsCounter,
+	)
 
 	return l
 }
@@ -66,32 +176,51 @@ func (l *IncidentMetricsListener) IncidentEventTriggered(event pagerduty.Webhook
 		pagerduty.IncidentAcknowledgedEventType,
 		pagerduty.IncidentUnacknowledgedEventType,
 		pagerduty.IncidentReassignedEventType,
-		pagerduty.IncidentPriorityUpdatedEventType,
 		pagerduty.IncidentDelegatedEventType,
 		pagerduty.IncidentEscalatedEventType,
 		pagerduty.IncidentReopenedEventType,
 		pagerduty.IncidentResolvedEventType:
 		return l.collectIncidentInfo(event)
+	case pagerduty.IncidentPriorityUpdatedEventType:
+		return l.collectIncidentPriorityUpdate(event)
+	case pagerduty.IncidentAnnotatedEventType:
+		return l.collectIncidentNote(event)
+	case pagerduty.IncidentResponderAddedEventType:
+		return l.collectIncidentResponderAdded(event)
+	case pagerduty.IncidentResponderRepliedEventType:
+		return l.collectIncidentResponderReplied(event)
+	case pagerduty.IncidentStatusUpdatePublishedEventType:
+		return l.collectIncidentStatusUpdate(event)
+	case pagerduty.IncidentConferenceBridgeUpdatedEventType:
+		return l.collectIncidentConferenceBridge(event)
+	case
+		pagerduty.ServiceCreatedEventType,
+		pagerduty.ServiceUpdatedEventType,
+		pagerduty.ServiceDeletedEventType:
+		return l.collectServiceEvent(event)
 	}
 
 	return nil
 }
 
 func (l *IncidentMetricsListener) collectIncidentInfo(event pagerduty.WebhookV3Event) error {
-	jsonData, err := json.Marshal(event.Data)
-	if err != nil {
-		return errors.Wrap(err, "marshal event data")
-	}
-
 	var incident pagerduty.WebhookV3Incident
 
-	if err := json.Unmarshal(jsonData, &incident); err != nil {
-		return errors.New("incident data must be with type popagerduty.Incident")
+	if err := event.DecodeData(&incident); err != nil {
+		return errors.Wrap(err, "decode incident event data")
 	}
 
+	l.setIncidentInfo(event, incident)
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) setIncidentInfo(event pagerduty.WebhookV3Event, incident pagerduty.WebhookV3Incident) {
+	l.incidentServices.Set(incident.Id, incident.Service.ID, event.OccurredAt)
+
 	occurredAtFormatted := event.OccurredAt.Format(l.dtFormat)
 
-	l.incidentEventGauge.With(prometheus.Labels{
+	l.incidentEventGauge.With(l.labelPolicy.Apply(l.withPriorityName(prometheus.Labels{
 		"incident_id":          incident.Id,
 		"type":                 incident.Type,
 		"status":               incident.Status,
@@ -103,27 +232,182 @@ func (l *IncidentMetricsListener) collectIncidentInfo(event pagerduty.WebhookV3E
 		"urgency":              incident.Urgency,
 		"priority_id":          incident.Priority.ID,
 		"dt":                   occurredAtFormatted,
-	}).Set(1)
+	}))).Set(1)
 
 	for i := range incident.Assignees {
-		l.incidentEventAssignees.With(prometheus.Labels{
+		l.incidentEventAssignees.With(l.labelPolicy.Apply(prometheus.Labels{
 			"incident_id":      incident.Id,
 			"event_type":       string(event.EventType),
 			"assignee_id":      incident.Assignees[i].ID,
 			"assignee_summary": incident.Assignees[i].Summary,
 			"dt":               occurredAtFormatted,
-		}).Set(1)
+		})).Set(1)
 	}
 
 	for i := range incident.Teams {
-		l.incidentEventTeams.With(prometheus.Labels{
+		l.incidentEventTeams.With(l.labelPolicy.Apply(prometheus.Labels{
 			"incident_id":  incident.Id,
 			"event_type":   string(event.EventType),
 			"team_id":      incident.Teams[i].ID,
 			"team_summary": incident.Teams[i].Summary,
 			"dt":           occurredAtFormatted,
-		}).Set(1)
+		})).Set(1)
 	}
+}
+
+func (l *IncidentMetricsListener) collectIncidentPriorityUpdate(event pagerduty.WebhookV3Event) error {
+	var incident pagerduty.WebhookV3Incident
+
+	if err := event.DecodeData(&incident); err != nil {
+		return errors.Wrap(err, "decode incident event data")
+	}
+
+	l.setIncidentInfo(event, incident)
+
+	l.incidentPriorityUpdatesCounter.With(l.labelPolicy.Apply(l.withPriorityName(prometheus.Labels{
+		"incident_id": incident.Id,
+		"service_id":  incident.Service.ID,
+		"priority_id": incident.Priority.ID,
+	}))).Inc()
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) collectIncidentNote(event pagerduty.WebhookV3Event) error {
+	var note pagerduty.WebhookV3IncidentNote
+
+	if err := event.DecodeData(&note); err != nil {
+		return errors.Wrap(err, "decode incident note event data")
+	}
+
+	l.incidentNotesCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"incident_id": note.Incident.ID,
+		"service_id":  l.incidentServiceID(note.Incident.ID),
+	})).Inc()
 
 	return nil
 }
+
+func (l *IncidentMetricsListener) collectIncidentResponderAdded(event pagerduty.WebhookV3Event) error {
+	var responder pagerduty.WebhookV3IncidentResponder
+
+	if err := event.DecodeData(&responder); err != nil {
+		return errors.Wrap(err, "decode incident responder event data")
+	}
+
+	l.pendingResponders.Set(responder.Incident.ID+"/"+responder.User.ID, "", event.OccurredAt)
+
+	l.incidentResponderRequestsCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"incident_id": responder.Incident.ID,
+		"service_id":  l.incidentServiceID(responder.Incident.ID),
+		"user_id":     responder.User.ID,
+	})).Inc()
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) collectIncidentResponderReplied(event pagerduty.WebhookV3Event) error {
+	var responder pagerduty.WebhookV3IncidentResponder
+
+	if err := event.DecodeData(&responder); err != nil {
+		return errors.Wrap(err, "decode incident responder event data")
+	}
+
+	serviceID := l.incidentServiceID(responder.Incident.ID)
+
+	l.incidentResponderRepliesCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"incident_id": responder.Incident.ID,
+		"service_id":  serviceID,
+		"user_id":     responder.User.ID,
+		"state":       responder.State,
+	})).Inc()
+
+	requestKey := responder.Incident.ID + "/" + responder.User.ID
+
+	request, ok := l.pendingResponders.Get(requestKey)
+	if !ok {
+		return nil
+	}
+
+	l.pendingResponders.Delete(requestKey)
+
+	l.incidentResponderReplyLatency.With(l.labelPolicy.Apply(prometheus.Labels{
+		"service_id": serviceID,
+		"state":      responder.State,
+	})).Observe(event.OccurredAt.Sub(request.updatedAt).Seconds())
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) collectIncidentStatusUpdate(event pagerduty.WebhookV3Event) error {
+	var statusUpdate pagerduty.WebhookV3IncidentStatusUpdate
+
+	if err := event.DecodeData(&statusUpdate); err != nil {
+		return errors.Wrap(err, "decode incident status update event data")
+	}
+
+	l.incidentStatusUpdatesCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"incident_id": statusUpdate.Incident.ID,
+		"service_id":  l.incidentServiceID(statusUpdate.Incident.ID),
+	})).Inc()
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) collectIncidentConferenceBridge(event pagerduty.WebhookV3Event) error {
+	var bridge pagerduty.WebhookV3IncidentConferenceBridge
+
+	if err := event.DecodeData(&bridge); err != nil {
+		return errors.Wrap(err, "decode incident conference bridge event data")
+	}
+
+	l.incidentConferenceBridgeCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"incident_id": bridge.Incident.ID,
+		"service_id":  l.incidentServiceID(bridge.Incident.ID),
+	})).Inc()
+
+	return nil
+}
+
+func (l *IncidentMetricsListener) collectServiceEvent(event pagerduty.WebhookV3Event) error {
+	var service pagerduty.WebhookV3Service
+
+	if err := event.DecodeData(&service); err != nil {
+		return errors.Wrap(err, "decode service event data")
+	}
+
+	l.serviceEventsCounter.With(l.labelPolicy.Apply(prometheus.Labels{
+		"service_id": service.ID,
+		"event_type": string(event.EventType),
+	})).Inc()
+
+	return nil
+}
+
+// incidentServiceID resolves service of the incident from previously received incident events,
+// as incident related events carry only the incident reference
+func (l *IncidentMetricsListener) incidentServiceID(incidentID string) string {
+	item, ok := l.incidentServices.Get(incidentID)
+	if !ok {
+		return unknownServiceIDLabel
+	}
+
+	return item.value
+}
+
+// withPriorityName adds priority_name resolved from priority_id to labels if priority names are enabled
+func (l *IncidentMetricsListener) withPriorityName(labels prometheus.Labels) prometheus.Labels {
+	if l.priorityNames != nil {
+		labels["priority_name"] = l.priorityNames.PriorityName(labels["priority_id"])
+	}
+
+	return labels
+}
+
+func withPriorityNameLabel(priorityNames PriorityNameResolver, labelNames []string) []string {
+	if priorityNames == nil {
+		return labelNames
+	}
+
+	return append(labelNames, "priority_name")
+}
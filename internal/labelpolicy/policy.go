@@ -0,0 +1,144 @@
+package labelpolicy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Action string
+
+const (
+	ActionKeep     Action = "keep"
+	ActionDrop     Action = "drop"
+	ActionHash     Action = "hash"
+	ActionTruncate Action = "truncate"
+)
+
+// hashLength is the number of hex chars of HMAC kept in hashed label values
+const hashLength = 16
+
+type rule struct {
+	action Action
+	length int
+}
+
+// Policy drops, hashes or truncates sensitive label values before they are exported.
+// Labels without rules are kept as is.
+type Policy struct {
+	rules   map[string]rule
+	hashKey []byte
+}
+
+// New parses label rules in label=action[:length] form, e.g. mail=hash, title=truncate:32, avatar=drop.
+// Hashing is keyed HMAC-SHA256 so hashKey is required by hash rules.
+func New(rules []string, hashKey []byte) (*Policy, error) {
+	p := &Policy{
+		rules:   make(map[string]rule, len(rules)),
+		hashKey: hashKey,
+	}
+
+	for _, r := range rules {
+		label, spec := splitPair(r, "=")
+		if label == "" || spec == "" {
+			return nil, fmt.Errorf("invalid label policy rule %q, expected label=action", r)
+		}
+
+		action, arg := splitPair(spec, ":")
+
+		rl := rule{action: Action(action)}
+
+		switch rl.action {
+		case ActionKeep, ActionDrop:
+		case ActionHash:
+			if len(hashKey) == 0 {
+				return nil, fmt.Errorf("label policy rule %q requires hash key", r)
+			}
+		case ActionTruncate:
+			length, err := strconv.Atoi(arg)
+			if err != nil || length <= 0 {
+				return nil, fmt.Errorf("label policy rule %q requires positive length, e.g. truncate:32", r)
+			}
+
+			rl.length = length
+		default:
+			return nil, fmt.Errorf("unsupported label policy action %q", action)
+		}
+
+		if rl.action != ActionTruncate && arg != "" {
+			return nil, fmt.Errorf("label policy action %s takes no arguments", action)
+		}
+
+		p.rules[label] = rl
+	}
+
+	return p, nil
+}
+
+// LabelNames filters out dropped labels, use it for metric vectors which values are passed to Apply
+func (p *Policy) LabelNames(names []string) []string {
+	filtered := make([]string, 0, len(names))
+
+	for _, n := range names {
+		if p.rules[n].action != ActionDrop {
+			filtered = append(filtered, n)
+		}
+	}
+
+	return filtered
+}
+
+// Apply applies the policy to label values in place and returns them
+func (p *Policy) Apply(labels prometheus.Labels) prometheus.Labels {
+	for name, value := range labels {
+		rl, ok := p.rules[name]
+		if !ok {
+			continue
+		}
+
+		switch rl.action {
+		case ActionDrop:
+			delete(labels, name)
+		case ActionHash:
+			labels[name] = p.hash(value)
+		case ActionTruncate:
+			labels[name] = truncate(value, rl.length)
+		}
+	}
+
+	return labels
+}
+
+func (p *Policy) hash(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, p.hashKey)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
+
+func splitPair(s, sep string) (string, string) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) == 1 {
+		return strings.TrimSpace(parts[0]), ""
+	}
+
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,176 @@
+package labelpolicy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		hashKey string
+
+		wantErr bool
+	}{
+		{
+			name:    "all actions",
+			rules:   []string{"mail=hash", "title = truncate:32", "avatar=drop", "name=keep"},
+			hashKey: "key",
+		},
+		{
+			name:    "hash without hash key",
+			rules:   []string{"mail=hash"},
+			wantErr: true,
+		},
+		{
+			name:    "truncate without length",
+			rules:   []string{"title=truncate"},
+			wantErr: true,
+		},
+		{
+			name:    "truncate with non positive length",
+			rules:   []string{"title=truncate:0"},
+			wantErr: true,
+		},
+		{
+			name:    "argument of action without arguments",
+			rules:   []string{"avatar=drop:1"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported action",
+			rules:   []string{"mail=encrypt"},
+			wantErr: true,
+		},
+		{
+			name:    "rule without action",
+			rules:   []string{"mail"},
+			wantErr: true,
+		},
+		{
+			name:    "rule without label",
+			rules:   []string{"=drop"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.rules, []byte(tt.hashKey))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("alice@example.com"))
+	hashedMail := hex.EncodeToString(mac.Sum(nil))[:16]
+
+	tests := []struct {
+		name   string
+		rules  []string
+		labels prometheus.Labels
+
+		want prometheus.Labels
+	}{
+		{
+			name:   "labels without rules are kept",
+			rules:  []string{"mail=hash"},
+			labels: prometheus.Labels{"user_id": "U1", "name": "Alice"},
+			want:   prometheus.Labels{"user_id": "U1", "name": "Alice"},
+		},
+		{
+			name:   "hash",
+			rules:  []string{"mail=hash"},
+			labels: prometheus.Labels{"user_id": "U1", "mail": "alice@example.com"},
+			want:   prometheus.Labels{"user_id": "U1", "mail": hashedMail},
+		},
+		{
+			name:   "empty value is not hashed",
+			rules:  []string{"mail=hash"},
+			labels: prometheus.Labels{"mail": ""},
+			want:   prometheus.Labels{"mail": ""},
+		},
+		{
+			name:   "drop",
+			rules:  []string{"avatar=drop"},
+			labels: prometheus.Labels{"user_id": "U1", "avatar": "https://example.com/a.png"},
+			want:   prometheus.Labels{"user_id": "U1"},
+		},
+		{
+			name:   "truncate counts runes",
+			rules:  []string{"title=truncate:5"},
+			labels: prometheus.Labels{"title": "Сбой базы данных"},
+			want:   prometheus.Labels{"title": "Сбой "},
+		},
+		{
+			name:   "value shorter than truncate length",
+			rules:  []string{"title=truncate:32"},
+			labels: prometheus.Labels{"title": "DB down"},
+			want:   prometheus.Labels{"title": "DB down"},
+		},
+		{
+			name:   "keep",
+			rules:  []string{"name=keep"},
+			labels: prometheus.Labels{"name": "Alice"},
+			want:   prometheus.Labels{"name": "Alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.rules, []byte("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := p.Apply(tt.labels)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() = %v, want %v", got, tt.want)
+			}
+
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("Apply()[%s] = %q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyLabelNames(t *testing.T) {
+	p, err := New([]string{"avatar=drop", "mail=hash", "title=truncate:32"}, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := p.LabelNames([]string{"user_id", "mail", "avatar", "title"})
+	want := []string{"user_id", "mail", "title"}
+
+	if len(got) != len(want) {
+		t.Fatalf("LabelNames() = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("LabelNames() = %v, want %v", got, want)
+		}
+	}
+
+	// the vector label names must match the labels left by Apply
+	labels := p.Apply(prometheus.Labels{"user_id": "U1", "mail": "m", "avatar": "a", "title": "t"})
+
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "users"}, got)
+	if _, err := vec.GetMetricWith(labels); err != nil {
+		t.Errorf("applied labels do not match label names: %v", err)
+	}
+}
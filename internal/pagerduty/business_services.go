@@ -0,0 +1,61 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const businessServiceImpactsPath = "/business_services/impacts"
+
+// businessServiceImpactsHeaders are required by /business_services/impacts which is in early access
+var businessServiceImpactsHeaders = map[string]string{
+	"X-EARLY-ACCESS": "business-impact-early-access",
+}
+
+type BusinessServiceImpact struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type listBusinessServiceImpactsPage struct {
+	Services   []BusinessServiceImpact `json:"services"`
+	NextCursor *string                 `json:"next_cursor"`
+}
+
+// ProbeBusinessServiceImpacts requests a single impact to check that the early access endpoint is reachable with the token
+func (c *ExtendedClient) ProbeBusinessServiceImpacts(ctx context.Context) error {
+	return c.probeEndpoint(ctx, businessServiceImpactsPath, businessServiceImpactsHeaders)
+}
+
+// ListBusinessServiceImpacts lists impact status of all business services following /business_services/impacts cursors
+func (c *ExtendedClient) ListBusinessServiceImpacts(ctx context.Context) ([]BusinessServiceImpact, error) {
+	var impacts []BusinessServiceImpact
+
+	path := businessServiceImpactsPath
+
+	for {
+		resp, err := c.do(ctx, http.MethodGet, path, nil, businessServiceImpactsHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		var page listBusinessServiceImpactsPage
+
+		if err := c.decodeJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("could not decode JSON response: %v", err)
+		}
+
+		impacts = append(impacts, page.Services...)
+
+		if page.NextCursor == nil || *page.NextCursor == "" {
+			break
+		}
+
+		path = businessServiceImpactsPath + "?cursor=" + url.QueryEscape(*page.NextCursor)
+	}
+
+	return impacts, nil
+}
@@ -2,6 +2,7 @@ package pagerduty
 
 import (
 	"context"
+	"time"
 
 	"github.com/PagerDuty/go-pagerduty"
 )
@@ -9,4 +10,34 @@ import (
 type Client interface {
 	QueryMetricReport(ctx context.Context, params ServiceMetricReportParams) (*Report, error)
 	ListUsersWithContext(ctx context.Context, o pagerduty.ListUsersOptions) (*pagerduty.ListUsersResponse, error)
+	ListServicesWithContext(ctx context.Context, o pagerduty.ListServiceOptions) (*pagerduty.ListServiceResponse, error)
+	ListEscalationPoliciesWithContext(
+		ctx context.Context,
+		o pagerduty.ListEscalationPoliciesOptions,
+	) (*pagerduty.ListEscalationPoliciesResponse, error)
+	ListAllPriorities(ctx context.Context) ([]Priority, error)
+	ListMaintenanceWindowsWithContext(
+		ctx context.Context,
+		o pagerduty.ListMaintenanceWindowsOptions,
+	) (*pagerduty.ListMaintenanceWindowsResponse, error)
+	ListBusinessServicesPaginated(
+		ctx context.Context,
+		o pagerduty.ListBusinessServiceOptions,
+	) ([]*pagerduty.BusinessService, error)
+	ListBusinessServiceImpacts(ctx context.Context) ([]BusinessServiceImpact, error)
+	ListBusinessServiceDependenciesWithContext(
+		ctx context.Context,
+		businessServiceID string,
+	) (*pagerduty.ListServiceDependencies, error)
+	ListTechnicalServiceDependenciesWithContext(
+		ctx context.Context,
+		serviceID string,
+	) (*pagerduty.ListServiceDependencies, error)
+	ListNotificationsWithContext(
+		ctx context.Context,
+		o pagerduty.ListNotificationOptions,
+	) (*pagerduty.ListNotificationsResponse, error)
+	ListAllLogEntries(ctx context.Context, since, until time.Time) ([]LogEntry, error)
+	ListAllTeams(ctx context.Context) ([]pagerduty.Team, error)
+	ListAllTeamMembers(ctx context.Context, teamID string) ([]TeamMember, error)
 }
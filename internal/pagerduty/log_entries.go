@@ -0,0 +1,79 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+const logEntriesRequestLimit = 100
+
+const (
+	NotifyLogEntryType      = "notify_log_entry"
+	EscalateLogEntryType    = "escalate_log_entry"
+	AcknowledgeLogEntryType = "acknowledge_log_entry"
+)
+
+// LogEntry keeps the log entry fields which are not decoded by go-pagerduty, e.g. notified user and notification type
+type LogEntry struct {
+	pagerduty.APIObject
+	CreatedAt time.Time           `json:"created_at"`
+	Agent     pagerduty.APIObject `json:"agent"`
+	User      pagerduty.APIObject `json:"user"`
+	Channel   LogEntryChannel     `json:"channel"`
+	Incident  LogEntryIncident    `json:"incident"`
+	// EscalationPolicy and Level are set on escalate log entries, Level is the escalation rule level the incident is escalated to
+	EscalationPolicy pagerduty.APIObject `json:"escalation_policy"`
+	Level            int                 `json:"level"`
+}
+
+type LogEntryChannel struct {
+	Type         string `json:"type"`
+	Notification *struct {
+		Type string `json:"type"`
+	} `json:"notification,omitempty"`
+}
+
+// LogEntryIncident is the incident included into the log entry with include[]=incidents
+type LogEntryIncident struct {
+	pagerduty.APIObject
+	EscalationPolicy pagerduty.APIObject `json:"escalation_policy"`
+}
+
+type listLogEntriesPage struct {
+	pagerduty.APIListObject
+	LogEntries []LogEntry `json:"log_entries"`
+}
+
+// ListAllLogEntries lists all log entries created in [since, until] paginating /log_entries
+func (c *ExtendedClient) ListAllLogEntries(ctx context.Context, since, until time.Time) ([]LogEntry, error) {
+	var entries []LogEntry
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(logEntriesRequestLimit))
+	params.Set("since", since.UTC().Format(time.RFC3339))
+	params.Set("until", until.UTC().Format(time.RFC3339))
+	params.Set("include[]", "incidents")
+
+	err := c.pagedGet(ctx, "/log_entries?"+params.Encode(), func(resp *http.Response) (pagerduty.APIListObject, error) {
+		var page listLogEntriesPage
+
+		if err := c.decodeJSON(resp, &page); err != nil {
+			return pagerduty.APIListObject{}, fmt.Errorf("could not decode JSON response: %v", err)
+		}
+
+		entries = append(entries, page.LogEntries...)
+
+		return page.APIListObject, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
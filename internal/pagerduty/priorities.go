@@ -0,0 +1,46 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+const prioritiesRequestLimit = 100
+
+// Priority extends go-pagerduty priority with the order which is not decoded by it
+type Priority struct {
+	pagerduty.APIObject
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Order       int    `json:"order"`
+}
+
+type listPrioritiesPage struct {
+	pagerduty.APIListObject
+	Priorities []Priority `json:"priorities"`
+}
+
+// ListAllPriorities lists all priorities paginating /priorities
+func (c *ExtendedClient) ListAllPriorities(ctx context.Context) ([]Priority, error) {
+	var priorities []Priority
+
+	err := c.pagedGet(ctx, fmt.Sprintf("/priorities?limit=%d", prioritiesRequestLimit), func(resp *http.Response) (pagerduty.APIListObject, error) {
+		var page listPrioritiesPage
+
+		if err := c.decodeJSON(resp, &page); err != nil {
+			return pagerduty.APIListObject{}, fmt.Errorf("could not decode JSON response: %v", err)
+		}
+
+		priorities = append(priorities, page.Priorities...)
+
+		return page.APIListObject, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return priorities, nil
+}
@@ -0,0 +1,73 @@
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+const teamsRequestLimit = 100
+
+type listTeamsPage struct {
+	pagerduty.APIListObject
+	Teams []pagerduty.Team `json:"teams"`
+}
+
+type TeamMember struct {
+	User pagerduty.APIObject `json:"user"`
+	Role string              `json:"role"`
+}
+
+type listTeamMembersPage struct {
+	pagerduty.APIListObject
+	Members []TeamMember `json:"members"`
+}
+
+// ListAllTeams lists all teams paginating /teams
+func (c *ExtendedClient) ListAllTeams(ctx context.Context) ([]pagerduty.Team, error) {
+	var teams []pagerduty.Team
+
+	err := c.pagedGet(ctx, fmt.Sprintf("/teams?limit=%d", teamsRequestLimit), func(resp *http.Response) (pagerduty.APIListObject, error) {
+		var page listTeamsPage
+
+		if err := c.decodeJSON(resp, &page); err != nil {
+			return pagerduty.APIListObject{}, fmt.Errorf("could not decode JSON response: %v", err)
+		}
+
+		teams = append(teams, page.Teams...)
+
+		return page.APIListObject, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// ListAllTeamMembers lists all team members paginating /teams/{id}/members
+func (c *ExtendedClient) ListAllTeamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	var members []TeamMember
+
+	basePath := fmt.Sprintf("/teams/%s/members?limit=%d", url.PathEscape(teamID), teamsRequestLimit)
+
+	err := c.pagedGet(ctx, basePath, func(resp *http.Response) (pagerduty.APIListObject, error) {
+		var page listTeamMembersPage
+
+		if err := c.decodeJSON(resp, &page); err != nil {
+			return pagerduty.APIListObject{}, fmt.Errorf("could not decode JSON response: %v", err)
+		}
+
+		members = append(members, page.Members...)
+
+		return page.APIListObject, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
@@ -0,0 +1,68 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+type TokenType string
+
+const (
+	TokenTypeUser    TokenType = "user"
+	TokenTypeAccount TokenType = "account"
+)
+
+type TokenInfo struct {
+	Type      TokenType
+	UserID    string
+	UserEmail string
+	Abilities []string
+}
+
+// InspectToken verifies the auth token and resolves its type and account abilities.
+// Account-level tokens are rejected by /users/me with 400, which is used to tell them apart.
+func (c *ExtendedClient) InspectToken(ctx context.Context) (*TokenInfo, error) {
+	abilities, err := c.ListAbilitiesWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list abilities: %w", err)
+	}
+
+	info := &TokenInfo{
+		Type:      TokenTypeAccount,
+		Abilities: abilities.Abilities,
+	}
+
+	user, err := c.GetCurrentUserWithContext(ctx, pagerduty.GetCurrentUserOptions{})
+	if err != nil {
+		var apiErr pagerduty.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			return info, nil
+		}
+
+		return nil, fmt.Errorf("get current user: %w", err)
+	}
+
+	info.Type = TokenTypeUser
+	info.UserID = user.ID
+	info.UserEmail = user.Email
+
+	return info, nil
+}
+
+// ProbeEndpoint requests a single item from the list endpoint to check that it is reachable with the token.
+func (c *ExtendedClient) ProbeEndpoint(ctx context.Context, path string) error {
+	return c.probeEndpoint(ctx, path, nil)
+}
+
+func (c *ExtendedClient) probeEndpoint(ctx context.Context, path string, headers map[string]string) error {
+	resp, err := c.do(ctx, http.MethodGet, getBasePrefix(path)+"limit=1", nil, headers)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
@@ -1,28 +1,68 @@
 package pagerduty
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"time"
 
 	"github.com/PagerDuty/go-pagerduty"
 )
 
+const WebhookSignatureHeader = "X-PagerDuty-Signature"
+
 type WebhookEventType string
 
 const (
-	IncidentAcknowledgedEventType     = "incident.acknowledged"
-	IncidentAnnotatedEventType        = "incident.annotated"
-	IncidentDelegatedEventType        = "incident.delegated"
-	IncidentEscalatedEventType        = "incident.escalated"
-	IncidentPriorityUpdatedEventType  = "incident.priority_updated"
-	IncidentReassignedEventType       = "incident.reassigned"
-	IncidentReopenedEventType         = "incident.reopened"
-	IncidentResolvedEventType         = "incident.resolved"
-	IncidentResponderAddedEventType   = "incident.responder.added"
-	IncidentResponderRepliedEventType = "incident.responder.replied"
-	IncidentTriggeredEventType        = "incident.triggered"
-	IncidentUnacknowledgedEventType   = "incident.unacknowledged"
+	PingEventType                            = "pagey.ping"
+	IncidentAcknowledgedEventType            = "incident.acknowledged"
+	IncidentAnnotatedEventType               = "incident.annotated"
+	IncidentConferenceBridgeUpdatedEventType = "incident.conference_bridge.updated"
+	IncidentDelegatedEventType               = "incident.delegated"
+	IncidentEscalatedEventType               = "incident.escalated"
+	IncidentPriorityUpdatedEventType         = "incident.priority_updated"
+	IncidentReassignedEventType              = "incident.reassigned"
+	IncidentReopenedEventType                = "incident.reopened"
+	IncidentResolvedEventType                = "incident.resolved"
+	IncidentResponderAddedEventType          = "incident.responder.added"
+	IncidentResponderRepliedEventType        = "incident.responder.replied"
+	IncidentStatusUpdatePublishedEventType   = "incident.status_update_published"
+	IncidentTriggeredEventType               = "incident.triggered"
+	IncidentUnacknowledgedEventType          = "incident.unacknowledged"
+	ServiceCreatedEventType                  = "service.created"
+	ServiceDeletedEventType                  = "service.deleted"
+	ServiceUpdatedEventType                  = "service.updated"
 )
 
+var knownWebhookEventTypes = map[WebhookEventType]struct{}{
+	PingEventType:                            {},
+	IncidentAcknowledgedEventType:            {},
+	IncidentAnnotatedEventType:               {},
+	IncidentConferenceBridgeUpdatedEventType: {},
+	IncidentDelegatedEventType:               {},
+	IncidentEscalatedEventType:               {},
+	IncidentPriorityUpdatedEventType:         {},
+	IncidentReassignedEventType:              {},
+	IncidentReopenedEventType:                {},
+	IncidentResolvedEventType:                {},
+	IncidentResponderAddedEventType:          {},
+	IncidentResponderRepliedEventType:        {},
+	IncidentStatusUpdatePublishedEventType:   {},
+	IncidentTriggeredEventType:               {},
+	IncidentUnacknowledgedEventType:          {},
+	ServiceCreatedEventType:                  {},
+	ServiceDeletedEventType:                  {},
+	ServiceUpdatedEventType:                  {},
+}
+
+// IsKnown reports whether the event type is one of documented pagerduty v3 webhook event types
+func (t WebhookEventType) IsKnown() bool {
+	_, ok := knownWebhookEventTypes[t]
+
+	return ok
+}
+
 type WebhookV3 struct {
 	Event WebhookV3Event `json:"event"`
 }
@@ -57,3 +97,71 @@ type WebhookV3Incident struct {
 	Id               string                     `json:"id,omitempty"`
 	ConferenceBridge pagerduty.ConferenceBridge `json:"conference_bridge,omitempty"`
 }
+
+// WebhookV3IncidentNote is the data of incident.annotated event
+type WebhookV3IncidentNote struct {
+	ID       string              `json:"id,omitempty"`
+	Type     string              `json:"type,omitempty"`
+	Incident pagerduty.APIObject `json:"incident,omitempty"`
+	Content  string              `json:"content,omitempty"`
+	Trimmed  bool                `json:"trimmed,omitempty"`
+}
+
+// WebhookV3IncidentResponder is the data of incident.responder.added and incident.responder.replied events
+type WebhookV3IncidentResponder struct {
+	Type             string              `json:"type,omitempty"`
+	Incident         pagerduty.APIObject `json:"incident,omitempty"`
+	User             pagerduty.APIObject `json:"user,omitempty"`
+	EscalationPolicy pagerduty.APIObject `json:"escalation_policy,omitempty"`
+	Message          string              `json:"message,omitempty"`
+	State            string              `json:"state,omitempty"`
+}
+
+// WebhookV3IncidentStatusUpdate is the data of incident.status_update_published event
+type WebhookV3IncidentStatusUpdate struct {
+	ID       string              `json:"id,omitempty"`
+	Type     string              `json:"type,omitempty"`
+	Incident pagerduty.APIObject `json:"incident,omitempty"`
+	Sender   pagerduty.APIObject `json:"sender,omitempty"`
+	Message  string              `json:"message,omitempty"`
+}
+
+// WebhookV3IncidentConferenceBridge is the data of incident.conference_bridge.updated event
+type WebhookV3IncidentConferenceBridge struct {
+	Type             string              `json:"type,omitempty"`
+	Incident         pagerduty.APIObject `json:"incident,omitempty"`
+	ConferenceNumber string              `json:"conference_number,omitempty"`
+	ConferenceURL    string              `json:"conference_url,omitempty"`
+}
+
+// WebhookV3Service is the data of service.* events
+type WebhookV3Service struct {
+	ID               string                `json:"id,omitempty"`
+	Type             string                `json:"type,omitempty"`
+	Self             string                `json:"self,omitempty"`
+	HTMLURL          string                `json:"html_url,omitempty"`
+	Summary          string                `json:"summary,omitempty"`
+	Name             string                `json:"name,omitempty"`
+	Description      string                `json:"description,omitempty"`
+	Status           string                `json:"status,omitempty"`
+	EscalationPolicy pagerduty.APIObject   `json:"escalation_policy,omitempty"`
+	Teams            []pagerduty.APIObject `json:"teams,omitempty"`
+}
+
+// DecodeData decodes event data into the payload model of the event type
+func (e WebhookV3Event) DecodeData(v interface{}) error {
+	jsonData, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonData, v)
+}
+
+// SignWebhookPayload computes payload signature the same way pagerduty does for X-PagerDuty-Signature header
+func SignWebhookPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload) // hash writes never fail
+
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,151 @@
+package pagerduty
+
+import (
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+const (
+	IncidentTriggerV2EventType       = "incident.trigger"
+	IncidentAcknowledgeV2EventType   = "incident.acknowledge"
+	IncidentUnacknowledgeV2EventType = "incident.unacknowledge"
+	IncidentResolveV2EventType       = "incident.resolve"
+	IncidentAssignV2EventType        = "incident.assign"
+	IncidentEscalateV2EventType      = "incident.escalate"
+	IncidentDelegateV2EventType      = "incident.delegate"
+	IncidentAnnotateV2EventType      = "incident.annotate"
+	IncidentReopenV2EventType        = "incident.reopen"
+)
+
+var v2ToV3EventTypes = map[string]WebhookEventType{
+	IncidentTriggerV2EventType:       IncidentTriggeredEventType,
+	IncidentAcknowledgeV2EventType:   IncidentAcknowledgedEventType,
+	IncidentUnacknowledgeV2EventType: IncidentUnacknowledgedEventType,
+	IncidentResolveV2EventType:       IncidentResolvedEventType,
+	IncidentAssignV2EventType:        IncidentReassignedEventType,
+	IncidentEscalateV2EventType:      IncidentEscalatedEventType,
+	IncidentDelegateV2EventType:      IncidentDelegatedEventType,
+	IncidentAnnotateV2EventType:      IncidentAnnotatedEventType,
+	IncidentReopenV2EventType:        IncidentReopenedEventType,
+}
+
+type WebhookV2 struct {
+	Messages []WebhookV2Message `json:"messages"`
+}
+
+type WebhookV2Message struct {
+	ID         string              `json:"id"`
+	Event      string              `json:"event"`
+	CreatedOn  time.Time           `json:"created_on"`
+	Incident   WebhookV2Incident   `json:"incident"`
+	LogEntries []WebhookV2LogEntry `json:"log_entries,omitempty"`
+}
+
+type WebhookV2LogEntry struct {
+	ID      string              `json:"id,omitempty"`
+	Type    string              `json:"type,omitempty"`
+	Agent   pagerduty.APIObject `json:"agent,omitempty"`
+	Channel struct {
+		Type    string `json:"type,omitempty"`
+		Summary string `json:"summary,omitempty"`
+		Content string `json:"content,omitempty"`
+	} `json:"channel,omitempty"`
+}
+
+type WebhookV2Assignment struct {
+	At       string              `json:"at,omitempty"`
+	Assignee pagerduty.APIObject `json:"assignee,omitempty"`
+}
+
+type WebhookV2Incident struct {
+	ID               string                `json:"id,omitempty"`
+	Type             string                `json:"type,omitempty"`
+	IncidentNumber   uint                  `json:"incident_number,omitempty"`
+	Title            string                `json:"title,omitempty"`
+	Status           string                `json:"status,omitempty"`
+	Urgency          string                `json:"urgency,omitempty"`
+	Self             string                `json:"self,omitempty"`
+	HTMLURL          string                `json:"html_url,omitempty"`
+	Service          pagerduty.APIObject   `json:"service,omitempty"`
+	EscalationPolicy pagerduty.APIObject   `json:"escalation_policy,omitempty"`
+	Assignments      []WebhookV2Assignment `json:"assignments,omitempty"`
+	Teams            []pagerduty.APIObject `json:"teams,omitempty"`
+	Priority         *pagerduty.APIObject  `json:"priority,omitempty"`
+}
+
+// V3EventType maps v2 message event to v3 event type, incident.custom and unknown events are not mapped
+func (m WebhookV2Message) V3EventType() (WebhookEventType, bool) {
+	et, ok := v2ToV3EventTypes[m.Event]
+
+	return et, ok
+}
+
+// ToV3Event converts v2 message into v3 event with the payload model of the v3 event type
+func (m WebhookV2Message) ToV3Event() (WebhookV3Event, bool) {
+	eventType, ok := m.V3EventType()
+	if !ok {
+		return WebhookV3Event{}, false
+	}
+
+	event := WebhookV3Event{
+		ID:           m.ID,
+		EventType:    eventType,
+		ResourceType: "incident",
+		OccurredAt:   m.CreatedOn,
+	}
+
+	if len(m.LogEntries) > 0 {
+		event.Agent = pagerduty.Agent(m.LogEntries[0].Agent)
+	}
+
+	incidentRef := pagerduty.APIObject{
+		ID:      m.Incident.ID,
+		Type:    "incident_reference",
+		Summary: m.Incident.Title,
+		Self:    m.Incident.Self,
+		HTMLURL: m.Incident.HTMLURL,
+	}
+
+	if eventType == IncidentAnnotatedEventType {
+		note := WebhookV3IncidentNote{
+			Type:     "incident_note",
+			Incident: incidentRef,
+		}
+
+		if len(m.LogEntries) > 0 {
+			note.ID = m.LogEntries[0].ID
+			note.Content = m.LogEntries[0].Channel.Content
+		}
+
+		event.Data = note
+
+		return event, true
+	}
+
+	incident := WebhookV3Incident{
+		Id:               m.Incident.ID,
+		Number:           m.Incident.IncidentNumber,
+		Title:            m.Incident.Title,
+		Type:             "incident",
+		Self:             m.Incident.Self,
+		HTMLURL:          m.Incident.HTMLURL,
+		Service:          m.Incident.Service,
+		EscalationPolicy: m.Incident.EscalationPolicy,
+		Teams:            m.Incident.Teams,
+		Urgency:          m.Incident.Urgency,
+		Status:           m.Incident.Status,
+	}
+
+	if m.Incident.Priority != nil {
+		incident.Priority = *m.Incident.Priority
+	}
+
+	for _, a := range m.Incident.Assignments {
+		incident.Assignees = append(incident.Assignees, a.Assignee)
+	}
+
+	event.Data = incident
+
+	return event, true
+}
@@ -0,0 +1,141 @@
+package relabel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+type Action string
+
+const (
+	ActionReplace   Action = "replace"
+	ActionKeep      Action = "keep"
+	ActionDrop      Action = "drop"
+	ActionLabelDrop Action = "labeldrop"
+	ActionLabelMap  Action = "labelmap"
+)
+
+const (
+	defaultSeparator   = ";"
+	defaultRegex       = "(.*)"
+	defaultReplacement = "$1"
+)
+
+type Config struct {
+	MetricRelabelConfigs []*Rule `yaml:"metric_relabel_configs"`
+}
+
+// Rule is modelled on prometheus metric_relabel_configs, MetricFamilies scopes the rule to the metric families
+// matching any of the regexes, the rule is applied to all families if empty
+type Rule struct {
+	MetricFamilies []string `yaml:"metric_families"`
+	SourceLabels   []string `yaml:"source_labels"`
+	Separator      *string  `yaml:"separator"`
+	Regex          *string  `yaml:"regex"`
+	TargetLabel    string   `yaml:"target_label"`
+	Replacement    *string  `yaml:"replacement"`
+	Action         Action   `yaml:"action"`
+
+	families []*regexp.Regexp
+	regex    *regexp.Regexp
+}
+
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read relabel config")
+	}
+
+	var cfg Config
+
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, errors.Wrap(err, "unmarshal relabel config")
+	}
+
+	for i, r := range cfg.MetricRelabelConfigs {
+		if err := r.complete(); err != nil {
+			return nil, errors.Wrapf(err, "relabel rule %d", i)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func (r *Rule) complete() error {
+	if r.Action == "" {
+		r.Action = ActionReplace
+	}
+
+	if r.Separator == nil {
+		separator := defaultSeparator
+		r.Separator = &separator
+	}
+
+	if r.Regex == nil {
+		regex := defaultRegex
+		r.Regex = &regex
+	}
+
+	if r.Replacement == nil {
+		replacement := defaultReplacement
+		r.Replacement = &replacement
+	}
+
+	var err error
+
+	r.regex, err = anchoredRegexp(*r.Regex)
+	if err != nil {
+		return errors.Wrap(err, "invalid regex")
+	}
+
+	for _, f := range r.MetricFamilies {
+		re, err := anchoredRegexp(f)
+		if err != nil {
+			return errors.Wrap(err, "invalid metric_families regex")
+		}
+
+		r.families = append(r.families, re)
+	}
+
+	switch r.Action {
+	case ActionReplace:
+		if !model.LabelName(r.TargetLabel).IsValid() || r.TargetLabel == model.MetricNameLabel {
+			return fmt.Errorf("replace action requires valid target_label, got %q", r.TargetLabel)
+		}
+	case ActionKeep, ActionDrop:
+		if len(r.SourceLabels) == 0 {
+			return fmt.Errorf("%s action requires source_labels", r.Action)
+		}
+	case ActionLabelDrop, ActionLabelMap:
+		if len(r.SourceLabels) > 0 || r.TargetLabel != "" {
+			return fmt.Errorf("%s action matches label names, source_labels and target_label are not allowed", r.Action)
+		}
+	default:
+		return fmt.Errorf("unsupported action %q", r.Action)
+	}
+
+	return nil
+}
+
+func (r *Rule) appliesTo(family string) bool {
+	if len(r.families) == 0 {
+		return true
+	}
+
+	for _, re := range r.families {
+		if re.MatchString(family) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func anchoredRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
@@ -0,0 +1,146 @@
+package relabel
+
+import "testing"
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+
+		wantErr    bool
+		wantAction Action
+	}{
+		{
+			name: "replace is the default action",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    target_label: service
+`,
+			wantAction: ActionReplace,
+		},
+		{
+			name: "replace without target label",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+`,
+			wantErr: true,
+		},
+		{
+			name: "replace of metric name",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    target_label: __name__
+`,
+			wantErr: true,
+		},
+		{
+			name: "keep without source labels",
+			config: `metric_relabel_configs:
+  - regex: api
+    action: keep
+`,
+			wantErr: true,
+		},
+		{
+			name: "labeldrop with source labels",
+			config: `metric_relabel_configs:
+  - source_labels: [mail]
+    regex: mail
+    action: labeldrop
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid regex",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    regex: '('
+    action: drop
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid metric families regex",
+			config: `metric_relabel_configs:
+  - metric_families: ['(']
+    regex: mail
+    action: labeldrop
+`,
+			wantErr: true,
+		},
+		{
+			name: "unsupported action",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    action: hashmod
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown field",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    target_label: service
+    modulus: 2
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := cfg.MetricRelabelConfigs[0].Action; got != tt.wantAction {
+				t.Errorf("action = %s, want %s", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestRuleAppliesTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		families []string
+		family   string
+
+		want bool
+	}{
+		{
+			name:   "rule without families applies to all families",
+			family: "pagerduty_user",
+			want:   true,
+		},
+		{
+			name:     "matching family",
+			families: []string{"pagerduty_service", "pagerduty_user.*"},
+			family:   "pagerduty_user_night_paging_load",
+			want:     true,
+		},
+		{
+			name:     "families are anchored",
+			families: []string{"pagerduty_user"},
+			family:   "pagerduty_user_night_paging_load",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{MetricFamilies: tt.families, Action: ActionLabelDrop}
+			if err := r.complete(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.appliesTo(tt.family); got != tt.want {
+				t.Errorf("appliesTo(%s) = %v, want %v", tt.family, got, tt.want)
+			}
+		})
+	}
+}
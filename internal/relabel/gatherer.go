@@ -0,0 +1,184 @@
+package relabel
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+// Gatherer applies relabel rules to gathered metric families before exposition.
+// Series which become duplicates after relabeling are dropped except the first one.
+type Gatherer struct {
+	gatherer prometheus.Gatherer
+	rules    []*Rule
+}
+
+func NewGatherer(gatherer prometheus.Gatherer, cfg *Config) *Gatherer {
+	return &Gatherer{
+		gatherer: gatherer,
+		rules:    cfg.MetricRelabelConfigs,
+	}
+}
+
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+
+	relabeled := mfs[:0]
+
+	for _, mf := range mfs {
+		if g.relabelFamily(mf) {
+			relabeled = append(relabeled, mf)
+		}
+	}
+
+	return relabeled, err
+}
+
+// relabelFamily relabels family metrics in place and reports whether any of them is kept
+func (g *Gatherer) relabelFamily(mf *dto.MetricFamily) bool {
+	var rules []*Rule
+
+	for _, r := range g.rules {
+		if r.appliesTo(mf.GetName()) {
+			rules = append(rules, r)
+		}
+	}
+
+	if len(rules) == 0 {
+		return len(mf.Metric) > 0
+	}
+
+	metrics := mf.Metric[:0]
+	seen := make(map[string]struct{}, len(mf.Metric))
+
+	for _, m := range mf.Metric {
+		labels := make(map[string]string, len(m.Label))
+		for _, lp := range m.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+
+		if !process(mf.GetName(), labels, rules) {
+			continue
+		}
+
+		m.Label = labelPairs(labels)
+
+		signature := labelsSignature(m.Label)
+		if _, ok := seen[signature]; ok {
+			continue
+		}
+
+		seen[signature] = struct{}{}
+		metrics = append(metrics, m)
+	}
+
+	mf.Metric = metrics
+
+	return len(mf.Metric) > 0
+}
+
+// process applies rules to labels in place and reports whether the series is kept
+func process(family string, labels map[string]string, rules []*Rule) bool {
+	for _, r := range rules {
+		switch r.Action {
+		case ActionKeep:
+			if !r.regex.MatchString(sourceValue(family, labels, r)) {
+				return false
+			}
+		case ActionDrop:
+			if r.regex.MatchString(sourceValue(family, labels, r)) {
+				return false
+			}
+		case ActionReplace:
+			value := sourceValue(family, labels, r)
+
+			idx := r.regex.FindStringSubmatchIndex(value)
+			if idx == nil {
+				continue
+			}
+
+			res := string(r.regex.ExpandString(nil, *r.Replacement, value, idx))
+			if res == "" {
+				delete(labels, r.TargetLabel)
+				continue
+			}
+
+			labels[r.TargetLabel] = res
+		case ActionLabelDrop:
+			for name := range labels {
+				if r.regex.MatchString(name) {
+					delete(labels, name)
+				}
+			}
+		case ActionLabelMap:
+			mapped := make(map[string]string)
+
+			for name, value := range labels {
+				if r.regex.MatchString(name) {
+					mapped[r.regex.ReplaceAllString(name, *r.Replacement)] = value
+				}
+			}
+
+			for name, value := range mapped {
+				if model.LabelName(name).IsValid() {
+					labels[name] = value
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func sourceValue(family string, labels map[string]string, r *Rule) string {
+	values := make([]string, len(r.SourceLabels))
+
+	for i, name := range r.SourceLabels {
+		if name == model.MetricNameLabel {
+			values[i] = family
+			continue
+		}
+
+		values[i] = labels[name]
+	}
+
+	return strings.Join(values, *r.Separator)
+}
+
+func labelPairs(labels map[string]string) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+
+	for name, value := range labels {
+		if value == "" {
+			continue
+		}
+
+		pairs = append(pairs, &dto.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(value),
+		})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].GetName() < pairs[j].GetName()
+	})
+
+	return pairs
+}
+
+func labelsSignature(pairs []*dto.LabelPair) string {
+	var sb strings.Builder
+
+	for _, lp := range pairs {
+		sb.WriteString(lp.GetName())
+		sb.WriteByte(0)
+		sb.WriteString(lp.GetValue())
+		sb.WriteByte(0)
+	}
+
+	return sb.String()
+}
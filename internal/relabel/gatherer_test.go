@@ -0,0 +1,196 @@
+package relabel
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func loadTestConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "relabel.yml")
+
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return LoadConfig(path)
+}
+
+// seriesStrings formats family series as name{label=value,...} sorted for comparison
+func seriesStrings(mfs []*dto.MetricFamily) []string {
+	var series []string
+
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			labels := make([]string, 0, len(m.Label))
+			for _, lp := range m.Label {
+				labels = append(labels, lp.GetName()+"="+lp.GetValue())
+			}
+
+			series = append(series, mf.GetName()+"{"+strings.Join(labels, ",")+"}")
+		}
+	}
+
+	sort.Strings(series)
+
+	return series
+}
+
+func TestGathererGather(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+
+		want []string
+	}{
+		{
+			name:   "no rules",
+			config: "metric_relabel_configs: []\n",
+			want: []string{
+				"pagerduty_service{service_id=S1,service_name=api}",
+				"pagerduty_service{service_id=S2,service_name=db}",
+				"pagerduty_user{mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "drop series",
+			config: `metric_relabel_configs:
+  - source_labels: [service_name]
+    regex: db
+    action: drop
+`,
+			want: []string{
+				"pagerduty_service{service_id=S1,service_name=api}",
+				"pagerduty_user{mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "keep series by metric name",
+			config: `metric_relabel_configs:
+  - source_labels: [__name__]
+    regex: pagerduty_user
+    action: keep
+`,
+			want: []string{
+				"pagerduty_user{mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "replace scoped to metric families",
+			config: `metric_relabel_configs:
+  - metric_families: [pagerduty_user]
+    source_labels: [mail]
+    regex: '.*@(.*)'
+    target_label: domain
+    replacement: $1
+`,
+			want: []string{
+				"pagerduty_service{service_id=S1,service_name=api}",
+				"pagerduty_service{service_id=S2,service_name=db}",
+				"pagerduty_user{domain=example.com,mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{domain=example.com,mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "replace with empty value removes the label",
+			config: `metric_relabel_configs:
+  - regex: '.*'
+    target_label: service_name
+    replacement: ''
+    metric_families: [pagerduty_service]
+`,
+			want: []string{
+				"pagerduty_service{service_id=S1}",
+				"pagerduty_service{service_id=S2}",
+				"pagerduty_user{mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "duplicates after labeldrop are dropped",
+			config: `metric_relabel_configs:
+  - regex: mail|user_id
+    action: labeldrop
+`,
+			want: []string{
+				"pagerduty_service{service_id=S1,service_name=api}",
+				"pagerduty_service{service_id=S2,service_name=db}",
+				"pagerduty_user{}",
+			},
+		},
+		{
+			name: "labelmap",
+			config: `metric_relabel_configs:
+  - regex: service_(.*)
+    replacement: pd_$1
+    action: labelmap
+`,
+			want: []string{
+				"pagerduty_service{pd_id=S1,pd_name=api,service_id=S1,service_name=api}",
+				"pagerduty_service{pd_id=S2,pd_name=db,service_id=S2,service_name=db}",
+				"pagerduty_user{mail=alice@example.com,user_id=U1}",
+				"pagerduty_user{mail=bob@example.com,user_id=U2}",
+			},
+		},
+		{
+			name: "family without kept series is dropped",
+			config: `metric_relabel_configs:
+  - source_labels: [user_id]
+    regex: U.*
+    action: drop
+`,
+			want: []string{
+				"pagerduty_service{service_id=S1,service_name=api}",
+				"pagerduty_service{service_id=S2,service_name=db}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadTestConfig(t, tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			registry := prometheus.NewRegistry()
+
+			services := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pagerduty_service"}, []string{"service_id", "service_name"})
+			services.WithLabelValues("S1", "api").Set(1)
+			services.WithLabelValues("S2", "db").Set(1)
+
+			users := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pagerduty_user"}, []string{"user_id", "mail"})
+			users.WithLabelValues("U1", "alice@example.com").Set(1)
+			users.WithLabelValues("U2", "bob@example.com").Set(1)
+
+			registry.MustRegister(services, users)
+
+			mfs, err := NewGatherer(registry, cfg).Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := seriesStrings(mfs)
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Gather() series:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			for _, mf := range mfs {
+				if len(mf.Metric) == 0 {
+					t.Errorf("family %s without series is gathered", mf.GetName())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package relay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultMaxRetries    = 3
+	defaultQueueSize     = 100
+	defaultRetryInterval = 30 * time.Second
+	defaultRetryMaxAge   = 24 * time.Hour
+)
+
+var destinationNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+type Config struct {
+	// RetryDir keeps failed deliveries on disk to retry them later, failed deliveries are dropped if empty
+	RetryDir      string              `yaml:"retry_dir"`
+	RetryInterval time.Duration       `yaml:"retry_interval"`
+	RetryMaxAge   time.Duration       `yaml:"retry_max_age"`
+	Destinations  []DestinationConfig `yaml:"destinations"`
+}
+
+type DestinationConfig struct {
+	Name       string        `yaml:"name"`
+	URL        string        `yaml:"url"`
+	Secret     string        `yaml:"secret"`
+	SecretFile string        `yaml:"secret_file"`
+	EventTypes []string      `yaml:"event_types"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxRetries int           `yaml:"max_retries"`
+	QueueSize  int           `yaml:"queue_size"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read relay config")
+	}
+
+	var cfg Config
+
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, errors.Wrap(err, "unmarshal relay config")
+	}
+
+	if err := cfg.complete(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) complete() error {
+	if c.RetryInterval == 0 {
+		c.RetryInterval = defaultRetryInterval
+	}
+
+	if c.RetryMaxAge == 0 {
+		c.RetryMaxAge = defaultRetryMaxAge
+	}
+
+	names := make(map[string]struct{}, len(c.Destinations))
+
+	for i := range c.Destinations {
+		d := &c.Destinations[i]
+
+		if !destinationNameRegexp.MatchString(d.Name) {
+			return fmt.Errorf("relay destination name %q must match %s", d.Name, destinationNameRegexp)
+		}
+
+		if _, ok := names[d.Name]; ok {
+			return fmt.Errorf("relay destination %s is duplicated", d.Name)
+		}
+
+		names[d.Name] = struct{}{}
+
+		if _, err := url.ParseRequestURI(d.URL); err != nil {
+			return errors.Wrapf(err, "relay destination %s url", d.Name)
+		}
+
+		if d.SecretFile != "" {
+			secret, err := ioutil.ReadFile(d.SecretFile)
+			if err != nil {
+				return errors.Wrapf(err, "relay destination %s secret file", d.Name)
+			}
+
+			d.Secret = strings.TrimSpace(string(secret))
+		}
+
+		for _, et := range d.EventTypes {
+			if !pagerduty.WebhookEventType(et).IsKnown() {
+				return fmt.Errorf("relay destination %s event type %s is unknown", d.Name, et)
+			}
+		}
+
+		if d.Timeout == 0 {
+			d.Timeout = defaultTimeout
+		}
+
+		if d.MaxRetries == 0 {
+			d.MaxRetries = defaultMaxRetries
+		}
+
+		if d.QueueSize == 0 {
+			d.QueueSize = defaultQueueSize
+		}
+	}
+
+	return nil
+}
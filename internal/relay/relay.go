@@ -0,0 +1,374 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+const (
+	dropReasonQueueFull  = "queue_full"
+	dropReasonNoRetryDir = "no_retry_dir"
+	dropReasonSpoolError = "spool_error"
+	dropReasonExpired    = "expired"
+
+	deliveryResultSuccess = "success"
+	deliveryResultFailure = "failure"
+
+	retryBackoff = time.Second
+)
+
+type metrics struct {
+	forwardedCounter  *prometheus.CounterVec
+	deliveriesCounter *prometheus.CounterVec
+	deliveryLatency   *prometheus.HistogramVec
+	droppedCounter    *prometheus.CounterVec
+}
+
+// Relay forwards verified webhook events to downstream destinations re-signed with destination secret
+type Relay struct {
+	logger        *zap.Logger
+	retryInterval time.Duration
+	retryMaxAge   time.Duration
+	destinations  []*destination
+
+	metrics metrics
+}
+
+type destination struct {
+	cfg        DestinationConfig
+	eventTypes map[pagerduty.WebhookEventType]struct{}
+	client     *http.Client
+	deliveries chan delivery
+	spool      *spool
+}
+
+func New(logger *zap.Logger, cfg *Config, registerer prometheus.Registerer) (*Relay, error) {
+	r := &Relay{
+		logger:        logger,
+		retryInterval: cfg.RetryInterval,
+		retryMaxAge:   cfg.RetryMaxAge,
+		destinations:  make([]*destination, len(cfg.Destinations)),
+		metrics: metrics{
+			forwardedCounter: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "pagerduty_webhook_relay_forwarded_total",
+					Help: "The number of webhook events forwarded to the destination.",
+				},
+				[]string{"destination"},
+			),
+			deliveriesCounter: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "pagerduty_webhook_relay_deliveries_total",
+					Help: "The number of delivery attempts to the destination by result.",
+				},
+				[]string{"destination", "result"},
+			),
+			deliveryLatency: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Name:    "pagerduty_webhook_relay_delivery_duration_seconds",
+					Help:    "The latency of delivery attempts to the destination.",
+					Buckets: prometheus.DefBuckets,
+				},
+				[]string{"destination"},
+			),
+			droppedCounter: prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "pagerduty_webhook_relay_dropped_total",
+					Help: "The number of webhook events which were not delivered to the destination by reason.",
+				},
+				[]string{"destination", "reason"},
+			),
+		},
+	}
+
+	registerer.MustRegister(
+		r.metrics.forwardedCounter,
+		r.metrics.deliveriesCounter,
+		r.metrics.deliveryLatency,
+		r.metrics.droppedCounter,
+	)
+
+	for i, dCfg := range cfg.Destinations {
+		d := &destination{
+			cfg:        dCfg,
+			eventTypes: make(map[pagerduty.WebhookEventType]struct{}, len(dCfg.EventTypes)),
+			client:     &http.Client{Timeout: dCfg.Timeout},
+			deliveries: make(chan delivery, dCfg.QueueSize),
+		}
+
+		for _, et := range dCfg.EventTypes {
+			d.eventTypes[pagerduty.WebhookEventType(et)] = struct{}{}
+		}
+
+		if cfg.RetryDir != "" {
+			s, err := newSpool(filepath.Join(cfg.RetryDir, dCfg.Name))
+			if err != nil {
+				return nil, err
+			}
+
+			d.spool = s
+
+			registerer.MustRegister(prometheus.NewGaugeFunc(
+				prometheus.GaugeOpts{
+					Name:        "pagerduty_webhook_relay_retry_queue_size",
+					Help:        "The number of deliveries waiting for retry on disk.",
+					ConstLabels: prometheus.Labels{"destination": dCfg.Name},
+				},
+				func() float64 { return float64(s.len()) },
+			))
+		}
+
+		r.destinations[i] = d
+	}
+
+	return r, nil
+}
+
+// Forward queues the event payload for delivery to every destination accepting the event type
+func (r *Relay) Forward(eventType pagerduty.WebhookEventType, payload []byte) {
+	for _, d := range r.destinations {
+		if !d.accepts(eventType) {
+			continue
+		}
+
+		r.metrics.forwardedCounter.WithLabelValues(d.cfg.Name).Inc()
+
+		dl := delivery{
+			EventType: string(eventType),
+			Payload:   payload,
+			CreatedAt: time.Now(),
+		}
+
+		select {
+		case d.deliveries <- dl:
+		default:
+			r.logger.Warn("relay destination queue is full", zap.String("destination", d.cfg.Name))
+			r.retryLater(d, dl, dropReasonQueueFull)
+		}
+	}
+}
+
+// Run delivers queued events and retries failed deliveries until ctx is done
+func (r *Relay) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for i := range r.destinations {
+		d := r.destinations[i]
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			r.deliverQueued(ctx, d)
+		}()
+
+		if d.spool == nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			r.retrySpooled(ctx, d)
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (r *Relay) deliverQueued(ctx context.Context, d *destination) {
+	for {
+		select {
+		case <-ctx.Done():
+			// keep events left in memory on disk to deliver them after restart
+			for {
+				select {
+				case dl := <-d.deliveries:
+					r.retryLater(d, dl, dropReasonNoRetryDir)
+				default:
+					return
+				}
+			}
+		case dl := <-d.deliveries:
+			if err := r.deliverWithRetries(ctx, d, dl); err != nil {
+				r.logger.Error(
+					"relay delivery failed",
+					zap.Error(err),
+					zap.String("destination", d.cfg.Name),
+					zap.String("event_type", dl.EventType),
+				)
+
+				r.retryLater(d, dl, dropReasonNoRetryDir)
+			}
+		}
+	}
+}
+
+func (r *Relay) deliverWithRetries(ctx context.Context, d *destination, dl delivery) error {
+	var err error
+
+	for attempt := 0; attempt <= d.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryBackoff << uint(attempt-1)):
+			}
+		}
+
+		if err = r.deliver(ctx, d, dl); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (r *Relay) retrySpooled(ctx context.Context, d *destination) {
+	ticker := time.NewTicker(r.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.retrySpooledOnce(ctx, d); err != nil {
+				r.logger.Error("relay retry failed", zap.Error(err), zap.String("destination", d.cfg.Name))
+			}
+		}
+	}
+}
+
+// retrySpooledOnce delivers spooled events the oldest first and stops on the first failure,
+// as the destination is most likely still unavailable
+func (r *Relay) retrySpooledOnce(ctx context.Context, d *destination) error {
+	names, err := d.spool.list()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		dl, err := d.spool.read(name)
+		if err != nil {
+			r.logger.Error(
+				"relay spooled delivery is unreadable, quarantined",
+				zap.Error(err),
+				zap.String("destination", d.cfg.Name),
+				zap.String("file", name),
+			)
+			r.metrics.droppedCounter.WithLabelValues(d.cfg.Name, dropReasonSpoolError).Inc()
+
+			if err := d.spool.quarantine(name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if time.Since(dl.CreatedAt) > r.retryMaxAge {
+			r.metrics.droppedCounter.WithLabelValues(d.cfg.Name, dropReasonExpired).Inc()
+
+			if err := d.spool.remove(name); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := r.deliver(ctx, d, dl); err != nil {
+			dl.Attempts++
+
+			return d.spool.update(name, dl)
+		}
+
+		if err := d.spool.remove(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Relay) retryLater(d *destination, dl delivery, noSpoolReason string) {
+	if d.spool == nil {
+		r.metrics.droppedCounter.WithLabelValues(d.cfg.Name, noSpoolReason).Inc()
+		return
+	}
+
+	if err := d.spool.put(dl); err != nil {
+		r.logger.Error("relay spool delivery failed", zap.Error(err), zap.String("destination", d.cfg.Name))
+		r.metrics.droppedCounter.WithLabelValues(d.cfg.Name, dropReasonSpoolError).Inc()
+	}
+}
+
+func (r *Relay) deliver(ctx context.Context, d *destination, dl delivery) error {
+	t := time.Now()
+
+	err := d.send(ctx, dl)
+
+	r.metrics.deliveryLatency.WithLabelValues(d.cfg.Name).Observe(time.Since(t).Seconds())
+
+	result := deliveryResultSuccess
+	if err != nil {
+		result = deliveryResultFailure
+	}
+
+	r.metrics.deliveriesCounter.WithLabelValues(d.cfg.Name, result).Inc()
+
+	return err
+}
+
+func (d *destination) accepts(eventType pagerduty.WebhookEventType) bool {
+	if len(d.eventTypes) == 0 {
+		return true
+	}
+
+	_, ok := d.eventTypes[eventType]
+
+	return ok
+}
+
+func (d *destination) send(ctx context.Context, dl delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pagerduty-prometheus-exporter-relay")
+
+	if d.cfg.Secret != "" {
+		req.Header.Set(pagerduty.WebhookSignatureHeader, pagerduty.SignWebhookPayload([]byte(d.cfg.Secret), dl.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
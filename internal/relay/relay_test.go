@@ -0,0 +1,375 @@
+package relay
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/pagerduty"
+)
+
+type receivedRequest struct {
+	signature string
+	body      string
+}
+
+// fakeDestination records received requests and responds with the codes in order, the last one repeats
+type fakeDestination struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	codes    []int
+	received []receivedRequest
+}
+
+func newFakeDestination(t *testing.T, codes ...int) *fakeDestination {
+	d := &fakeDestination{codes: codes}
+
+	d.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		d.received = append(d.received, receivedRequest{
+			signature: r.Header.Get(pagerduty.WebhookSignatureHeader),
+			body:      string(body),
+		})
+
+		code := http.StatusOK
+		if len(d.codes) > 0 {
+			code = d.codes[0]
+		}
+
+		if len(d.codes) > 1 {
+			d.codes = d.codes[1:]
+		}
+
+		w.WriteHeader(code)
+	}))
+
+	t.Cleanup(d.Close)
+
+	return d
+}
+
+func (d *fakeDestination) requests() []receivedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]receivedRequest(nil), d.received...)
+}
+
+func newTestRelay(t *testing.T, retryDir string, destinations ...DestinationConfig) *Relay {
+	t.Helper()
+
+	cfg := &Config{
+		RetryDir:      retryDir,
+		RetryInterval: time.Hour,
+		RetryMaxAge:   time.Hour,
+		Destinations:  destinations,
+	}
+
+	for i := range cfg.Destinations {
+		cfg.Destinations[i].Timeout = time.Second
+	}
+
+	r, err := New(zap.NewNop(), cfg, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestDestinationSend(t *testing.T) {
+	payload := []byte(`{"event":{"event_type":"incident.triggered"}}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(payload)
+	signature := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name   string
+		secret string
+		code   int
+
+		wantErr       bool
+		wantSignature string
+	}{
+		{
+			name:          "payload is signed with destination secret",
+			secret:        "secret",
+			code:          http.StatusAccepted,
+			wantSignature: signature,
+		},
+		{
+			name: "payload is not signed without destination secret",
+			code: http.StatusOK,
+		},
+		{
+			name:          "non 2xx response",
+			secret:        "secret",
+			code:          http.StatusInternalServerError,
+			wantErr:       true,
+			wantSignature: signature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fd := newFakeDestination(t, tt.code)
+
+			r := newTestRelay(t, "", DestinationConfig{Name: "downstream", URL: fd.URL, Secret: tt.secret})
+
+			err := r.destinations[0].send(context.Background(), delivery{Payload: payload})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			received := fd.requests()
+			if len(received) != 1 {
+				t.Fatalf("destination received %d requests, want 1", len(received))
+			}
+
+			if received[0].body != string(payload) {
+				t.Errorf("body = %s, want %s", received[0].body, payload)
+			}
+
+			if received[0].signature != tt.wantSignature {
+				t.Errorf("signature = %q, want %q", received[0].signature, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestRelayForward(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		queueSize  int
+		retryDir   bool
+		forwarded  []pagerduty.WebhookEventType
+
+		wantQueued  int
+		wantSpooled int64
+		wantDropped float64
+	}{
+		{
+			name:       "events are queued for every accepted event type",
+			eventTypes: []string{pagerduty.IncidentTriggeredEventType},
+			queueSize:  10,
+			forwarded:  []pagerduty.WebhookEventType{pagerduty.IncidentTriggeredEventType, pagerduty.IncidentResolvedEventType},
+			wantQueued: 1,
+		},
+		{
+			name:       "all event types are accepted without event types",
+			queueSize:  10,
+			forwarded:  []pagerduty.WebhookEventType{pagerduty.IncidentTriggeredEventType, pagerduty.IncidentResolvedEventType},
+			wantQueued: 2,
+		},
+		{
+			name:        "events over the full queue are spooled",
+			queueSize:   1,
+			retryDir:    true,
+			forwarded:   []pagerduty.WebhookEventType{pagerduty.IncidentTriggeredEventType, pagerduty.IncidentResolvedEventType},
+			wantQueued:  1,
+			wantSpooled: 1,
+		},
+		{
+			name:        "events over the full queue are dropped without retry dir",
+			queueSize:   1,
+			forwarded:   []pagerduty.WebhookEventType{pagerduty.IncidentTriggeredEventType, pagerduty.IncidentResolvedEventType},
+			wantQueued:  1,
+			wantDropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retryDir string
+			if tt.retryDir {
+				retryDir = t.TempDir()
+			}
+
+			r := newTestRelay(t, retryDir, DestinationConfig{
+				Name:       "downstream",
+				URL:        "http://127.0.0.1:0",
+				EventTypes: tt.eventTypes,
+				QueueSize:  tt.queueSize,
+			})
+
+			for _, et := range tt.forwarded {
+				r.Forward(et, []byte(`{}`))
+			}
+
+			d := r.destinations[0]
+
+			if len(d.deliveries) != tt.wantQueued {
+				t.Errorf("queued %d deliveries, want %d", len(d.deliveries), tt.wantQueued)
+			}
+
+			if d.spool != nil && d.spool.len() != tt.wantSpooled {
+				t.Errorf("spooled %d deliveries, want %d", d.spool.len(), tt.wantSpooled)
+			}
+
+			dropped := testutil.ToFloat64(r.metrics.droppedCounter.WithLabelValues("downstream", dropReasonQueueFull))
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped %v deliveries, want %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRelayRetrySpooledOnce(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		codes    []int
+		spooled  []delivery
+		corrupt  bool
+		received []string
+
+		wantSpooled  []string
+		wantAttempts int
+		wantDropped  map[string]float64
+	}{
+		{
+			name:  "spooled deliveries are delivered the oldest first",
+			codes: []int{http.StatusOK},
+			spooled: []delivery{
+				{Payload: []byte(`"second"`), CreatedAt: now.Add(-time.Minute)},
+				{Payload: []byte(`"first"`), CreatedAt: now.Add(-2 * time.Minute)},
+			},
+			received: []string{`"first"`, `"second"`},
+		},
+		{
+			name:  "retry stops on the first failure",
+			codes: []int{http.StatusOK, http.StatusServiceUnavailable},
+			spooled: []delivery{
+				{Payload: []byte(`"first"`), CreatedAt: now.Add(-3 * time.Minute)},
+				{Payload: []byte(`"second"`), CreatedAt: now.Add(-2 * time.Minute)},
+				{Payload: []byte(`"third"`), CreatedAt: now.Add(-time.Minute)},
+			},
+			received:     []string{`"first"`, `"second"`},
+			wantSpooled:  []string{`"second"`, `"third"`},
+			wantAttempts: 1,
+		},
+		{
+			name:  "expired deliveries are dropped",
+			codes: []int{http.StatusOK},
+			spooled: []delivery{
+				{Payload: []byte(`"expired"`), CreatedAt: now.Add(-2 * time.Hour)},
+				{Payload: []byte(`"fresh"`), CreatedAt: now.Add(-time.Minute)},
+			},
+			received:    []string{`"fresh"`},
+			wantDropped: map[string]float64{dropReasonExpired: 1},
+		},
+		{
+			name:  "unreadable deliveries are quarantined",
+			codes: []int{http.StatusOK},
+			spooled: []delivery{
+				{Payload: []byte(`"fresh"`), CreatedAt: now.Add(-time.Minute)},
+			},
+			corrupt:     true,
+			received:    []string{`"fresh"`},
+			wantDropped: map[string]float64{dropReasonSpoolError: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fd := newFakeDestination(t, tt.codes...)
+
+			r := newTestRelay(t, t.TempDir(), DestinationConfig{Name: "downstream", URL: fd.URL})
+			d := r.destinations[0]
+
+			for _, dl := range tt.spooled {
+				if err := d.spool.put(dl); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.corrupt {
+				if err := d.spool.write("00000000000000000000-000000.json", []byte("{")); err != nil {
+					t.Fatal(err)
+				}
+
+				d.spool.size++
+			}
+
+			if err := r.retrySpooledOnce(context.Background(), d); err != nil {
+				t.Fatal(err)
+			}
+
+			received := fd.requests()
+			if len(received) != len(tt.received) {
+				t.Fatalf("destination received %d requests, want %d", len(received), len(tt.received))
+			}
+
+			for i := range received {
+				if received[i].body != tt.received[i] {
+					t.Errorf("request %d body = %s, want %s", i, received[i].body, tt.received[i])
+				}
+			}
+
+			names, err := d.spool.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(names) != len(tt.wantSpooled) || d.spool.len() != int64(len(tt.wantSpooled)) {
+				t.Fatalf("spool has %d files and size %d, want %d", len(names), d.spool.len(), len(tt.wantSpooled))
+			}
+
+			for i, name := range names {
+				dl, err := d.spool.read(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(dl.Payload) != tt.wantSpooled[i] {
+					t.Errorf("spooled delivery %d = %s, want %s", i, dl.Payload, tt.wantSpooled[i])
+				}
+
+				if i == 0 && dl.Attempts != tt.wantAttempts {
+					t.Errorf("failed delivery attempts = %d, want %d", dl.Attempts, tt.wantAttempts)
+				}
+			}
+
+			for _, reason := range []string{dropReasonExpired, dropReasonSpoolError} {
+				got := testutil.ToFloat64(r.metrics.droppedCounter.WithLabelValues("downstream", reason))
+				if got != tt.wantDropped[reason] {
+					t.Errorf("dropped %v deliveries by %s, want %v", got, reason, tt.wantDropped[reason])
+				}
+			}
+
+			if tt.corrupt {
+				matches, err := filepath.Glob(filepath.Join(d.spool.dir, "*"+quarantineFileExt))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(matches) != 1 {
+					t.Errorf("quarantined %d files, want 1", len(matches))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	spoolFileExt      = ".json"
+	quarantineFileExt = ".corrupt"
+)
+
+type delivery struct {
+	EventType string    `json:"event_type"`
+	Payload   []byte    `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+	Attempts  int       `json:"attempts"`
+}
+
+// spool is the disk-backed retry queue of a destination, each delivery is kept in its own file
+type spool struct {
+	dir  string
+	seq  uint64
+	size int64
+}
+
+func newSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "create retry dir")
+	}
+
+	s := &spool{dir: dir}
+
+	names, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+
+	s.size = int64(len(names))
+
+	return s, nil
+}
+
+func (s *spool) put(d delivery) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return errors.Wrap(err, "marshal delivery")
+	}
+
+	name := fmt.Sprintf("%020d-%06d%s", d.CreatedAt.UnixNano(), atomic.AddUint64(&s.seq, 1)%1000000, spoolFileExt)
+
+	if err := s.write(name, b); err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&s.size, 1)
+
+	return nil
+}
+
+// list returns spooled delivery names, the oldest first
+func (s *spool) list() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read retry dir")
+	}
+
+	names := make([]string, 0, len(files))
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), spoolFileExt) {
+			continue
+		}
+
+		names = append(names, f.Name())
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *spool) read(name string) (delivery, error) {
+	var d delivery
+
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return d, errors.Wrap(err, "read delivery")
+	}
+
+	if err := json.Unmarshal(b, &d); err != nil {
+		return d, errors.Wrap(err, "unmarshal delivery")
+	}
+
+	return d, nil
+}
+
+func (s *spool) update(name string, d delivery) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return errors.Wrap(err, "marshal delivery")
+	}
+
+	return s.write(name, b)
+}
+
+func (s *spool) remove(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+		return errors.Wrap(err, "remove delivery")
+	}
+
+	atomic.AddInt64(&s.size, -1)
+
+	return nil
+}
+
+// quarantine moves unreadable delivery out of the spool, so it is kept for inspection but never retried,
+// the file is removed if it can not be moved
+func (s *spool) quarantine(name string) error {
+	path := filepath.Join(s.dir, name)
+
+	if err := os.Rename(path, path+quarantineFileExt); err != nil {
+		if err := os.Remove(path); err != nil {
+			return errors.Wrap(err, "remove unreadable delivery")
+		}
+	}
+
+	atomic.AddInt64(&s.size, -1)
+
+	return nil
+}
+
+func (s *spool) len() int64 {
+	return atomic.LoadInt64(&s.size)
+}
+
+// write replaces the file atomically so a crash never leaves partially written delivery
+func (s *spool) write(name string, b []byte) error {
+	tmp, err := ioutil.TempFile(s.dir, name+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "create delivery file")
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return errors.Wrap(err, "write delivery file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return errors.Wrap(err, "close delivery file")
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return errors.Wrap(err, "rename delivery file")
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package relay
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpool(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "downstream")
+	now := time.Now()
+
+	s, err := newSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dl := range []delivery{
+		{EventType: "incident.resolved", Payload: []byte(`"second"`), CreatedAt: now},
+		{EventType: "incident.triggered", Payload: []byte(`"first"`), CreatedAt: now.Add(-time.Second)},
+		{EventType: "incident.acknowledged", Payload: []byte(`"third"`), CreatedAt: now},
+	} {
+		if err := s.put(dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := s.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`"first"`, `"second"`, `"third"`}
+
+	if len(names) != len(want) || s.len() != int64(len(want)) {
+		t.Fatalf("spool has %d files and size %d, want %d", len(names), s.len(), len(want))
+	}
+
+	for i, name := range names {
+		dl, err := s.read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(dl.Payload) != want[i] {
+			t.Errorf("delivery %d = %s, want %s", i, dl.Payload, want[i])
+		}
+	}
+
+	dl, err := s.read(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dl.Attempts++
+
+	if err := s.update(names[0], dl); err != nil {
+		t.Fatal(err)
+	}
+
+	if dl, err = s.read(names[0]); err != nil || dl.Attempts != 1 || !dl.CreatedAt.Equal(now.Add(-time.Second)) {
+		t.Errorf("updated delivery = %+v, %v, want 1 attempt", dl, err)
+	}
+
+	if err := s.remove(names[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.quarantine(names[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// the size is restored from the files left after restart
+	s, err = newSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err = s.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 1 || s.len() != 1 {
+		t.Fatalf("spool has %d files and size %d after restart, want 1", len(names), s.len())
+	}
+
+	if dl, err := s.read(names[0]); err != nil || string(dl.Payload) != `"third"` {
+		t.Errorf("delivery left = %s, %v, want %s", dl.Payload, err, `"third"`)
+	}
+}
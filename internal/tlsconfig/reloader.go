@@ -0,0 +1,188 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const reloadCheckInterval = 5 * time.Second
+
+type Config struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c Config) Validate() error {
+	if c.Enabled() && (c.CertFile == "" || c.KeyFile == "") {
+		return errors.New("both tls cert and key files must be set")
+	}
+
+	if c.ClientCAFile != "" && !c.Enabled() {
+		return errors.New("tls client ca file requires tls cert and key files")
+	}
+
+	return nil
+}
+
+// Reloader serves TLS config reloading certificate, key and client CA files once they are changed on disk
+type Reloader struct {
+	logger *zap.Logger
+	cfg    Config
+
+	mu          sync.Mutex
+	config      *tls.Config
+	modTimes    []time.Time
+	lastChecked time.Time
+}
+
+func NewReloader(logger *zap.Logger, cfg Config) (*Reloader, error) {
+	r := &Reloader{
+		logger: logger,
+		cfg:    cfg,
+	}
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.config, r.modTimes, r.lastChecked = config, modTimes, time.Now()
+
+	return r, nil
+}
+
+// TLSConfig returns server TLS config resolving actual certificates per connection
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+	}
+}
+
+// ClientCertRequired reports whether clients must present certificate signed by client CA
+func (r *Reloader) ClientCertRequired() bool {
+	return r.cfg.ClientCAFile != ""
+}
+
+func (r *Reloader) current() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastChecked) < reloadCheckInterval {
+		return r.config
+	}
+
+	r.lastChecked = time.Now()
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		r.logger.Error("tls files stat failed, keeping loaded config", zap.Error(err))
+		return r.config
+	}
+
+	if equalTimes(modTimes, r.modTimes) {
+		return r.config
+	}
+
+	config, err := r.load()
+	if err != nil {
+		r.logger.Error("tls config reload failed, keeping loaded config", zap.Error(err))
+		return r.config
+	}
+
+	r.logger.Info("tls config reloaded", zap.String("cert_file", r.cfg.CertFile))
+
+	r.config, r.modTimes = config, modTimes
+
+	return r.config
+}
+
+func (r *Reloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load tls key pair")
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.cfg.ClientCAFile == "" {
+		return config, nil
+	}
+
+	caPEM, err := ioutil.ReadFile(r.cfg.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read tls client ca file")
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+	}
+
+	config.ClientCAs = clientCAs
+	// clients without certificate pass the handshake and are rejected by handlers,
+	// so the rejections can be accounted
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return config, nil
+}
+
+func (r *Reloader) statFiles() ([]time.Time, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	modTimes := make([]time.Time, len(files))
+
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "stat tls file")
+		}
+
+		modTimes[i] = fi.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
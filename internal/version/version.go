@@ -0,0 +1,30 @@
+package version
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Version and Commit are set at build time with
+// -ldflags "-X github.com/24el/pagerduty-prometheus-exporter/internal/version.Version=... -X ...Commit=..."
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+// NewBuildInfoCollector returns pagerduty_exporter_build_info collector, it is always 1
+func NewBuildInfoCollector() prometheus.Collector {
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "pagerduty_exporter_build_info",
+		Help: "The exporter build info.",
+		ConstLabels: prometheus.Labels{
+			"version":   Version,
+			"commit":    Commit,
+			"goversion": runtime.Version(),
+		},
+	})
+	buildInfo.Set(1)
+
+	return buildInfo
+}
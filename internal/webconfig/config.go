@@ -0,0 +1,122 @@
+package webconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/tlsconfig"
+)
+
+const (
+	NoClientCert               = "NoClientCert"
+	VerifyClientCertIfGiven    = "VerifyClientCertIfGiven"
+	RequireAndVerifyClientCert = "RequireAndVerifyClientCert"
+)
+
+// Config is the web config file in the style of prometheus exporter-toolkit
+type Config struct {
+	TLSServerConfig *TLSServerConfig `yaml:"tls_server_config"`
+	// BasicAuthUsers maps usernames to bcrypt hashed passwords
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+	BearerTokens   []string          `yaml:"bearer_tokens"`
+}
+
+type TLSServerConfig struct {
+	CertFile       string `yaml:"cert_file"`
+	KeyFile        string `yaml:"key_file"`
+	ClientCAFile   string `yaml:"client_ca_file"`
+	ClientAuthType string `yaml:"client_auth_type"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read web config")
+	}
+
+	var cfg Config
+
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, errors.Wrap(err, "unmarshal web config")
+	}
+
+	if err := cfg.complete(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) TLSEnabled() bool {
+	return c.TLSServerConfig != nil
+}
+
+func (c *Config) AuthEnabled() bool {
+	return len(c.BasicAuthUsers) > 0 || len(c.BearerTokens) > 0
+}
+
+func (c *Config) tlsConfig() tlsconfig.Config {
+	return tlsconfig.Config{
+		CertFile:     c.TLSServerConfig.CertFile,
+		KeyFile:      c.TLSServerConfig.KeyFile,
+		ClientCAFile: c.TLSServerConfig.ClientCAFile,
+	}
+}
+
+func (c *Config) clientCertRequired() bool {
+	return c.TLSEnabled() && c.TLSServerConfig.ClientAuthType == RequireAndVerifyClientCert
+}
+
+func (c *Config) complete(dir string) error {
+	for user, hash := range c.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return errors.Wrapf(err, "basic auth user %s password must be bcrypt hashed", user)
+		}
+	}
+
+	for _, token := range c.BearerTokens {
+		if token == "" {
+			return errors.New("bearer tokens must not be empty")
+		}
+	}
+
+	t := c.TLSServerConfig
+	if t == nil {
+		return nil
+	}
+
+	// file paths are relative to the web config file as in exporter-toolkit
+	for _, f := range []*string{&t.CertFile, &t.KeyFile, &t.ClientCAFile} {
+		if *f != "" && !filepath.IsAbs(*f) {
+			*f = filepath.Join(dir, *f)
+		}
+	}
+
+	if t.CertFile == "" || t.KeyFile == "" {
+		return errors.New("tls_server_config requires cert_file and key_file")
+	}
+
+	if t.ClientAuthType == "" {
+		t.ClientAuthType = NoClientCert
+		if t.ClientCAFile != "" {
+			t.ClientAuthType = RequireAndVerifyClientCert
+		}
+	}
+
+	switch t.ClientAuthType {
+	case NoClientCert:
+	case VerifyClientCertIfGiven, RequireAndVerifyClientCert:
+		if t.ClientCAFile == "" {
+			return fmt.Errorf("client_auth_type %s requires client_ca_file", t.ClientAuthType)
+		}
+	default:
+		return fmt.Errorf("unsupported client_auth_type %q", t.ClientAuthType)
+	}
+
+	return nil
+}
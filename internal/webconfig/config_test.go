@@ -0,0 +1,88 @@
+package webconfig
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+
+		wantErr            bool
+		wantClientAuthType string
+		wantCertFile       string
+	}{
+		{
+			name: "bcrypt hashed password",
+			cfg: Config{BasicAuthUsers: map[string]string{
+				"alice": "$2a$04$dTx66vf8rXRmD3rK4gZTvOg2lr5MR1nr9ayxzq4hbBLNj6kD0ubWu",
+			}},
+		},
+		{
+			name:    "plain text password",
+			cfg:     Config{BasicAuthUsers: map[string]string{"alice": "secret"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty bearer token",
+			cfg:     Config{BearerTokens: []string{"token", ""}},
+			wantErr: true,
+		},
+		{
+			name:    "tls without key file",
+			cfg:     Config{TLSServerConfig: &TLSServerConfig{CertFile: "tls.crt"}},
+			wantErr: true,
+		},
+		{
+			name:               "tls without client ca",
+			cfg:                Config{TLSServerConfig: &TLSServerConfig{CertFile: "tls.crt", KeyFile: "tls.key"}},
+			wantClientAuthType: NoClientCert,
+			wantCertFile:       filepath.Join("/etc/exporter", "tls.crt"),
+		},
+		{
+			name: "client ca requires client cert by default",
+			cfg: Config{TLSServerConfig: &TLSServerConfig{
+				CertFile: "/tls/tls.crt", KeyFile: "/tls/tls.key", ClientCAFile: "ca.crt",
+			}},
+			wantClientAuthType: RequireAndVerifyClientCert,
+			wantCertFile:       "/tls/tls.crt",
+		},
+		{
+			name: "client cert verification without client ca",
+			cfg: Config{TLSServerConfig: &TLSServerConfig{
+				CertFile: "tls.crt", KeyFile: "tls.key", ClientAuthType: VerifyClientCertIfGiven,
+			}},
+			wantErr: true,
+		},
+		{
+			name: "unsupported client auth type",
+			cfg: Config{TLSServerConfig: &TLSServerConfig{
+				CertFile: "tls.crt", KeyFile: "tls.key", ClientAuthType: "RequireAnyClientCert",
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.complete("/etc/exporter")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr || tt.cfg.TLSServerConfig == nil {
+				return
+			}
+
+			if tt.cfg.TLSServerConfig.ClientAuthType != tt.wantClientAuthType {
+				t.Errorf("client auth type = %s, want %s", tt.cfg.TLSServerConfig.ClientAuthType, tt.wantClientAuthType)
+			}
+
+			if tt.cfg.TLSServerConfig.CertFile != tt.wantCertFile {
+				t.Errorf("cert file = %s, want %s", tt.cfg.TLSServerConfig.CertFile, tt.wantCertFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package webconfig
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/24el/pagerduty-prometheus-exporter/internal/tlsconfig"
+)
+
+const reloadCheckInterval = 5 * time.Second
+
+// WebConfig protects http server with TLS and authentication from the web config file
+// reloading the file once it is changed on disk
+type WebConfig struct {
+	logger     *zap.Logger
+	path       string
+	tlsEnabled bool
+
+	mu          sync.Mutex
+	cfg         *Config
+	tlsConfig   *tls.Config
+	modTime     time.Time
+	lastChecked time.Time
+	// authCache keeps successfully checked basic auth credentials to not run bcrypt on every request
+	authCache map[[sha256.Size]byte]struct{}
+}
+
+func New(logger *zap.Logger, path string) (*WebConfig, error) {
+	w := &WebConfig{
+		logger: logger,
+		path:   path,
+	}
+
+	modTime, err := w.statFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, tlsConfig, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	w.cfg, w.tlsConfig, w.modTime, w.lastChecked = cfg, tlsConfig, modTime, time.Now()
+	w.authCache = make(map[[sha256.Size]byte]struct{})
+	w.tlsEnabled = cfg.TLSEnabled()
+
+	return w, nil
+}
+
+// TLSConfig returns server TLS config resolving actual config per connection, nil if TLS is disabled.
+// TLS can't be enabled or disabled without restart.
+func (w *WebConfig) TLSConfig() *tls.Config {
+	if !w.tlsEnabled {
+		return nil
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// ListenAndServeTLS without files requires Certificates or GetCertificate set
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			_, tlsConfig := w.current()
+			if tlsConfig == nil {
+				return nil, errors.New("tls is disabled in web config")
+			}
+
+			return tlsConfig.GetCertificate(hello)
+		},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			_, tlsConfig := w.current()
+			if tlsConfig == nil {
+				return nil, errors.New("tls is disabled in web config")
+			}
+
+			return tlsConfig.GetConfigForClient(hello)
+		},
+	}
+}
+
+// Middleware rejects requests without client certificate or valid credentials required by the web config
+func (w *WebConfig) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		cfg, _ := w.current()
+
+		if cfg.clientCertRequired() && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if cfg.AuthEnabled() && !w.authenticated(cfg, r) {
+			if len(cfg.BasicAuthUsers) > 0 {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="pagerduty-prometheus-exporter"`)
+			} else {
+				rw.Header().Set("WWW-Authenticate", "Bearer")
+			}
+
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (w *WebConfig) authenticated(cfg *Config, r *http.Request) bool {
+	if user, pass, ok := r.BasicAuth(); ok {
+		hash, ok := cfg.BasicAuthUsers[user]
+		if !ok {
+			return false
+		}
+
+		key := sha256.Sum256([]byte(user + "\x00" + pass + "\x00" + hash))
+
+		w.mu.Lock()
+		_, cached := w.authCache[key]
+		w.mu.Unlock()
+
+		if cached {
+			return true
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) != nil {
+			return false
+		}
+
+		w.mu.Lock()
+		w.authCache[key] = struct{}{}
+		w.mu.Unlock()
+
+		return true
+	}
+
+	const bearerPrefix = "Bearer "
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, bearerPrefix) {
+		return false
+	}
+
+	token := []byte(strings.TrimPrefix(auth, bearerPrefix))
+
+	for _, t := range cfg.BearerTokens {
+		if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *WebConfig) current() (*Config, *tls.Config) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if time.Since(w.lastChecked) < reloadCheckInterval {
+		return w.cfg, w.tlsConfig
+	}
+
+	w.lastChecked = time.Now()
+
+	modTime, err := w.statFile()
+	if err != nil {
+		w.logger.Error("web config stat failed, keeping loaded config", zap.Error(err))
+		return w.cfg, w.tlsConfig
+	}
+
+	if modTime.Equal(w.modTime) {
+		return w.cfg, w.tlsConfig
+	}
+
+	cfg, tlsConfig, err := w.load()
+	if err != nil {
+		w.logger.Error("web config reload failed, keeping loaded config", zap.Error(err))
+		return w.cfg, w.tlsConfig
+	}
+
+	if cfg.TLSEnabled() != w.tlsEnabled {
+		w.logger.Error("web config reload failed, tls can't be enabled or disabled without restart")
+		return w.cfg, w.tlsConfig
+	}
+
+	w.logger.Info("web config reloaded", zap.String("path", w.path))
+
+	w.cfg, w.tlsConfig, w.modTime = cfg, tlsConfig, modTime
+	w.authCache = make(map[[sha256.Size]byte]struct{})
+
+	return w.cfg, w.tlsConfig
+}
+
+func (w *WebConfig) load() (*Config, *tls.Config, error) {
+	cfg, err := LoadConfig(w.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cfg.TLSEnabled() {
+		return cfg, nil, nil
+	}
+
+	reloader, err := tlsconfig.NewReloader(w.logger, cfg.tlsConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, reloader.TLSConfig(), nil
+}
+
+func (w *WebConfig) statFile() (time.Time, error) {
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "stat web config")
+	}
+
+	return fi.ModTime(), nil
+}
@@ -0,0 +1,199 @@
+package webconfig
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestWebConfig(t *testing.T, content string) *WebConfig {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "web-config.yml")
+
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(zap.NewNop(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(hash)
+}
+
+func TestWebConfigMiddleware(t *testing.T) {
+	basicAuthConfig := "basic_auth_users:\n  alice: " + bcryptHash(t, "secret") + "\n"
+	bearerConfig := "bearer_tokens:\n  - token1\n  - token2\n"
+
+	tests := []struct {
+		name          string
+		config        string
+		user          string
+		password      string
+		authorization string
+
+		wantCode            int
+		wantWWWAuthenticate string
+	}{
+		{
+			name:     "no auth configured",
+			config:   "{}\n",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "basic auth valid password",
+			config:   basicAuthConfig,
+			user:     "alice",
+			password: "secret",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:                "basic auth wrong password",
+			config:              basicAuthConfig,
+			user:                "alice",
+			password:            "wrong",
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: `Basic realm="pagerduty-prometheus-exporter"`,
+		},
+		{
+			name:                "basic auth unknown user",
+			config:              basicAuthConfig,
+			user:                "bob",
+			password:            "secret",
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: `Basic realm="pagerduty-prometheus-exporter"`,
+		},
+		{
+			name:                "basic auth without credentials",
+			config:              basicAuthConfig,
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: `Basic realm="pagerduty-prometheus-exporter"`,
+		},
+		{
+			name:          "bearer token",
+			config:        bearerConfig,
+			authorization: "Bearer token2",
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:                "wrong bearer token",
+			config:              bearerConfig,
+			authorization:       "Bearer token3",
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: "Bearer",
+		},
+		{
+			name:                "bearer token prefix",
+			config:              bearerConfig,
+			authorization:       "Bearer token",
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: "Bearer",
+		},
+		{
+			name:                "token without bearer scheme",
+			config:              bearerConfig,
+			authorization:       "token1",
+			wantCode:            http.StatusUnauthorized,
+			wantWWWAuthenticate: "Bearer",
+		},
+		{
+			name:          "bearer token with basic auth configured",
+			config:        basicAuthConfig + bearerConfig,
+			authorization: "Bearer token1",
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:     "basic auth with bearer tokens configured",
+			config:   basicAuthConfig + bearerConfig,
+			user:     "alice",
+			password: "secret",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWebConfig(t, tt.config)
+
+			handler := w.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+			if tt.user != "" {
+				r.SetBasicAuth(tt.user, tt.password)
+			}
+
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			if rw.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", rw.Code, tt.wantCode)
+			}
+
+			if got := rw.Header().Get("WWW-Authenticate"); got != tt.wantWWWAuthenticate {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantWWWAuthenticate)
+			}
+		})
+	}
+}
+
+func TestWebConfigAuthenticatedCache(t *testing.T) {
+	w := newTestWebConfig(t, "basic_auth_users:\n  alice: "+bcryptHash(t, "secret")+"\n")
+	cfg, _ := w.current()
+
+	authenticated := func(user, password string) bool {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.SetBasicAuth(user, password)
+
+		return w.authenticated(cfg, r)
+	}
+
+	if authenticated("alice", "wrong") {
+		t.Fatal("wrong password is authenticated")
+	}
+
+	if len(w.authCache) != 0 {
+		t.Fatal("failed credentials are cached")
+	}
+
+	if !authenticated("alice", "secret") {
+		t.Fatal("valid password is not authenticated")
+	}
+
+	if len(w.authCache) != 1 {
+		t.Fatalf("auth cache has %d credentials, want 1", len(w.authCache))
+	}
+
+	// the cache is keyed by the hash too, so credentials checked against a replaced hash are not reused
+	cfg.BasicAuthUsers["alice"] = bcryptHash(t, "changed")
+
+	if authenticated("alice", "secret") {
+		t.Error("cached password is authenticated against the changed hash")
+	}
+
+	if !authenticated("alice", "changed") {
+		t.Error("changed password is not authenticated")
+	}
+}